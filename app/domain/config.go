@@ -1,17 +1,77 @@
 package domain
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type Config struct {
 	Providers  []ProviderConfig `koanf:"providers"`
 	Files      FileConfig       `koanf:"files"`
 	Identifier IdentifierConfig `koanf:"identifier"`
 	Git        GitConfig        `koanf:"git"`
+	Run        RunConfig        `koanf:"run"`
+	Retry      RetryConfig      `koanf:"retry"`
 }
 
 type ProviderConfig struct {
 	Provider string `koanf:"provider"`
 	Username string `koanf:"username"`
 	Org      string `koanf:"org"`
-	Token    string `koanf:"token"`
+	// Orgs and Users add more orgs (groups, workspaces) and user namespaces discovered with the same credentials.
+	Orgs        []string `koanf:"orgs"`
+	Users       []string `koanf:"users"`
+	Token       string   `koanf:"token"`
+	Concurrency int      `koanf:"concurrency"`
+	PageSize    int      `koanf:"pageSize"`
+	// BaseURL points the provider at a self-hosted instance, e.g. GitHub Enterprise Server.
+	// UploadURL is the GitHub Enterprise upload endpoint and defaults to BaseURL.
+	BaseURL   string `koanf:"baseUrl"`
+	UploadURL string `koanf:"uploadUrl"`
+	// Repositories lists the repositories of a static provider, which does no discovery.
+	Repositories []StaticRepository `koanf:"repositories"`
+	// Path is the directory a local provider searches for bare repositories.
+	Path string `koanf:"path"`
+	// App authenticates the github provider as a GitHub App instead of with Token.
+	App GithubAppConfig `koanf:"app"`
+	// Filter skips discovered repositories before they are cloned.
+	Filter RepositoryFilter `koanf:"filter"`
+	// Discovery is DiscoveryList (the default) or DiscoverySearch.
+	Discovery string `koanf:"discovery"`
+	// ProbeIdentifier turns off reading the identifier file through the provider API before cloning when false.
+	// Unset means probe wherever the provider supports it.
+	ProbeIdentifier *bool `koanf:"probeIdentifier"`
+
+	// userNamespace is set on the configs returned by Namespaces when Org names a user rather than an org.
+	userNamespace bool
+}
+
+// Discovery modes for ProviderConfig.Discovery. DiscoverySearch finds candidates through code search for the
+// identifier file and lists every repository when the provider cannot search.
+const (
+	DiscoveryList   = "list"
+	DiscoverySearch = "search"
+)
+
+// GithubAppConfig identifies a GitHub App installation. When InstallationID is zero the
+// installation is looked up from the provider's org.
+type GithubAppConfig struct {
+	AppID          int64  `koanf:"appId"`
+	InstallationID int64  `koanf:"installationId"`
+	PrivateKeyFile string `koanf:"privateKeyFile"`
+}
+
+// Configured reports whether GitHub App authentication is set up.
+func (c GithubAppConfig) Configured() bool { return c.AppID != 0 }
+
+// StaticRepository is a repository listed directly in the config of a static provider.
+// Name defaults to the last path segment of Url. PullRequestProvider names the provider type
+// (e.g. github) used to open pull requests on it; without one only direct pushes are possible.
+type StaticRepository struct {
+	Url                 string `koanf:"url"`
+	Name                string `koanf:"name"`
+	PullRequestProvider string `koanf:"pullRequestProvider"`
 }
 
 type FileConfig struct {
@@ -31,7 +91,68 @@ type GitConfig struct {
 	TargetBranch string `koanf:"targetBranch"`
 }
 
+// RunConfig controls how repositories are processed during a run.
+// Concurrency is the maximum number of repositories processed at once across all providers.
+// Timeout bounds the whole run and RepoTimeout bounds each repository; zero means no limit.
+// Targets restricts the run to a subset of providers and repositories.
+type RunConfig struct {
+	Concurrency int           `koanf:"concurrency"`
+	Hosts       []HostLimit   `koanf:"hosts"`
+	Timeout     time.Duration `koanf:"timeout"`
+	RepoTimeout time.Duration `koanf:"repoTimeout"`
+	Targets     TargetFilter  `koanf:"targets"`
+}
+
+// HostLimit caps the number of repositories processed at once for a single git host (e.g. github.com).
+type HostLimit struct {
+	Host        string `koanf:"host"`
+	Concurrency int    `koanf:"concurrency"`
+}
+
 type RemoteConfig struct {
 	Reviewers []string `koanf:"reviewers"`
 	Accepts   []string `koanf:"accepts"`
 }
+
+// UserNamespace reports whether Org names a user rather than an org. It is only set on the configs returned by
+// Namespaces.
+func (p ProviderConfig) UserNamespace() bool { return p.userNamespace }
+
+// ProbesIdentifier reports whether the identifier file should be read through the provider API before cloning.
+func (p ProviderConfig) ProbesIdentifier() bool {
+	return p.ProbeIdentifier == nil || *p.ProbeIdentifier
+}
+
+// Namespaces splits the provider entry into one config per org and user namespace, in the order they are
+// configured and without duplicates. Each config has a single Org and no Orgs or Users. An entry without any
+// namespace (e.g. a static provider) is returned unchanged.
+func (p ProviderConfig) Namespaces() []ProviderConfig {
+	var out []ProviderConfig
+	seen := map[string]struct{}{}
+	add := func(name string, user bool) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return
+		}
+		key := fmt.Sprintf("%t/%s", user, strings.ToLower(name))
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		c := p
+		c.Org, c.Orgs, c.Users, c.userNamespace = name, nil, nil, user
+		out = append(out, c)
+	}
+
+	add(p.Org, p.userNamespace)
+	for _, org := range p.Orgs {
+		add(org, false)
+	}
+	for _, user := range p.Users {
+		add(user, true)
+	}
+	if len(out) == 0 {
+		return []ProviderConfig{p}
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RepositoryFilter skips discovered repositories that should never be synced. Include and Exclude are
+// regular expressions compared to the repository name and its path (e.g. my-org/my-repo); a repository is kept
+// when it matches any Include (or there are none) and no Exclude. Visibility and Topics keep repositories with
+// one of the listed values. ActiveWithin skips repositories with no activity for that long; repositories whose
+// provider does not report activity are kept.
+type RepositoryFilter struct {
+	SkipArchived bool          `koanf:"skipArchived"`
+	SkipForks    bool          `koanf:"skipForks"`
+	Visibility   []string      `koanf:"visibility"`
+	Topics       []string      `koanf:"topics"`
+	Include      []string      `koanf:"include"`
+	Exclude      []string      `koanf:"exclude"`
+	ActiveWithin time.Duration `koanf:"activeWithin"`
+}
+
+// Visibilities lists the values accepted by RepositoryFilter.Visibility.
+var Visibilities = []string{VisibilityPublic, VisibilityPrivate, VisibilityInternal}
+
+// IsZero reports whether the filter keeps every repository.
+func (f RepositoryFilter) IsZero() bool {
+	return !f.SkipArchived && !f.SkipForks && len(f.Visibility) == 0 && len(f.Topics) == 0 &&
+		len(f.Include) == 0 && len(f.Exclude) == 0 && f.ActiveWithin == 0
+}
+
+// RepositoryMatcher is the compiled form of a RepositoryFilter. A nil matcher keeps every repository.
+type RepositoryMatcher struct {
+	filter  RepositoryFilter
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	now     func() time.Time
+}
+
+// NewRepositoryMatcher compiles f, returning an error for invalid expressions or visibilities.
+func NewRepositoryMatcher(f RepositoryFilter) (*RepositoryMatcher, error) {
+	m := &RepositoryMatcher{filter: f, now: time.Now}
+	for _, v := range f.Visibility {
+		if !slices.Contains(Visibilities, strings.ToLower(strings.TrimSpace(v))) {
+			return nil, fmt.Errorf("unknown visibility %q, expected one of: %s", v, strings.Join(Visibilities, ", "))
+		}
+	}
+	var err error
+	if m.include, err = compileAll(f.Include); err != nil {
+		return nil, err
+	}
+	if m.exclude, err = compileAll(f.Exclude); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// SkipsArchived reports whether archived repositories are skipped, so providers can exclude them server side.
+func (m *RepositoryMatcher) SkipsArchived() bool { return m != nil && m.filter.SkipArchived }
+
+// SkipsForks reports whether forks are skipped, so providers can exclude them server side.
+func (m *RepositoryMatcher) SkipsForks() bool { return m != nil && m.filter.SkipForks }
+
+// Filter returns the repositories the filter keeps, in their original order.
+func (m *RepositoryMatcher) Filter(repos []GitRepository) []GitRepository {
+	if m == nil {
+		return repos
+	}
+	kept := make([]GitRepository, 0, len(repos))
+	for _, repo := range repos {
+		if m.Match(repo) {
+			kept = append(kept, repo)
+		}
+	}
+	return kept
+}
+
+// Match reports whether the filter keeps repo.
+func (m *RepositoryMatcher) Match(repo GitRepository) bool {
+	if m == nil {
+		return true
+	}
+	f := m.filter
+	if f.SkipArchived && repo.Archived {
+		return false
+	}
+	if f.SkipForks && repo.Fork {
+		return false
+	}
+	if len(f.Visibility) > 0 && !containsFold(f.Visibility, repo.Visibility) {
+		return false
+	}
+	if len(f.Topics) > 0 && !slices.ContainsFunc(repo.Topics, func(t string) bool { return containsFold(f.Topics, t) }) {
+		return false
+	}
+	if f.ActiveWithin > 0 && !repo.LastActivity.IsZero() && m.now().Sub(repo.LastActivity) > f.ActiveWithin {
+		return false
+	}
+
+	candidates := matchCandidates(repo)
+	if len(m.include) > 0 && !matchesAny(m.include, candidates) {
+		return false
+	}
+	return !matchesAny(m.exclude, candidates)
+}
+
+// matchesAny reports whether any of the patterns matches any of the candidates.
+func matchesAny(patterns []*regexp.Regexp, candidates []string) bool {
+	for _, re := range patterns {
+		for _, c := range candidates {
+			if re.MatchString(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, s string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(strings.TrimSpace(v), s) })
+}
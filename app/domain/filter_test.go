@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRepositoryMatcher_Match(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	base := GitRepository{Name: "api", Url: "https://github.com/acme/api.git", Visibility: VisibilityPrivate, Topics: []string{"go"}, LastActivity: now.Add(-24 * time.Hour)}
+	with := func(change func(*GitRepository)) GitRepository {
+		repo := base
+		change(&repo)
+		return repo
+	}
+
+	cases := []struct {
+		name   string
+		filter RepositoryFilter
+		repo   GitRepository
+		want   bool
+	}{
+		{name: "empty filter", repo: with(func(r *GitRepository) { r.Archived, r.Fork = true, true }), want: true},
+		{name: "archived", filter: RepositoryFilter{SkipArchived: true}, repo: with(func(r *GitRepository) { r.Archived = true })},
+		{name: "fork", filter: RepositoryFilter{SkipForks: true}, repo: with(func(r *GitRepository) { r.Fork = true })},
+		{name: "not a fork", filter: RepositoryFilter{SkipForks: true}, repo: base, want: true},
+		{name: "visibility", filter: RepositoryFilter{Visibility: []string{"Public"}}, repo: base},
+		{name: "visibility matches", filter: RepositoryFilter{Visibility: []string{"public", "private"}}, repo: base, want: true},
+		{name: "topic", filter: RepositoryFilter{Topics: []string{"GO", "java"}}, repo: base, want: true},
+		{name: "missing topic", filter: RepositoryFilter{Topics: []string{"java"}}, repo: base},
+		{name: "include by path", filter: RepositoryFilter{Include: []string{"^acme/"}}, repo: base, want: true},
+		{name: "not included", filter: RepositoryFilter{Include: []string{"^svc-"}}, repo: base},
+		{name: "include by short name", filter: RepositoryFilter{Include: []string{"^api$"}}, repo: with(func(r *GitRepository) { r.Name, r.Url = "group/sub/api", "https://gitlab.com/group/sub/api.git" }), want: true},
+		{name: "excluded", filter: RepositoryFilter{Exclude: []string{"^ap"}}, repo: base},
+		{name: "inactive", filter: RepositoryFilter{ActiveWithin: time.Hour}, repo: base},
+		{name: "active", filter: RepositoryFilter{ActiveWithin: 48 * time.Hour}, repo: base, want: true},
+		{name: "activity unknown", filter: RepositoryFilter{ActiveWithin: time.Hour}, repo: with(func(r *GitRepository) { r.LastActivity = time.Time{} }), want: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewRepositoryMatcher(tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			m.now = func() time.Time { return now }
+			if got := m.Match(tc.repo); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_Invalid(t *testing.T) {
+	if _, err := NewRepositoryMatcher(RepositoryFilter{Exclude: []string{"["}}); err == nil {
+		t.Fatalf("expected an error for an invalid pattern")
+	}
+	if _, err := NewRepositoryMatcher(RepositoryFilter{Visibility: []string{"secret"}}); err == nil {
+		t.Fatalf("expected an error for an unknown visibility")
+	}
+}
+
+func TestRepositoryMatcher_NilKeepsEverything(t *testing.T) {
+	var m *RepositoryMatcher
+	repos := []GitRepository{{Name: "a", Archived: true}}
+	if got := m.Filter(repos); len(got) != 1 {
+		t.Fatalf("expected a nil matcher to keep every repository, got %v", got)
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
-	billy "github.com/go-git/go-billy/v5"
+	billy "github.com/go-git/go-billy/v6"
 	gogit "github.com/go-git/go-git/v6"
 )
 
@@ -23,7 +24,14 @@ func SetSkeletonName(name string) {
 }
 
 type GitRepositoryProvider interface {
-	GetRepositories() (*[]GitRepository, error)
+	GetRepositories(ctx context.Context) (*[]GitRepository, error)
+}
+
+// RepositorySearcher is implemented by providers that can find the repositories containing a file through
+// code search, which is much faster than listing every repository of a large org.
+type RepositorySearcher interface {
+	// SearchRepositories returns the repositories with a file at path, or an error when search is unavailable.
+	SearchRepositories(ctx context.Context, path string) (*[]GitRepository, error)
 }
 
 // PRBodyBuilder builds the body/description for a pull request given context about the change.
@@ -62,12 +70,57 @@ type PullRequestManager interface {
 }
 
 type GitOperations interface {
-	CloneGit(repo GitRepository, config ProviderConfig) (*gogit.Repository, billy.Filesystem, error)
-	IsValidForBoneClone(repo *gogit.Repository, config Config) (bool, RemoteConfig, error)
-	CopyFiles(repo *gogit.Repository, fs billy.Filesystem, config Config, provider ProviderConfig, targetBranch string) error
+	CloneGit(ctx context.Context, repo GitRepository, config ProviderConfig) (*gogit.Repository, billy.Filesystem, error)
+	// IsValidForBoneClone returns ErrIdentifierNotFound when the identifier file is missing.
+	IsValidForBoneClone(ctx context.Context, repo *gogit.Repository, config Config) (bool, RemoteConfig, error)
+	// CopyFiles copies, commits and pushes the configured files, reporting upToDate when there was nothing to push.
+	CopyFiles(ctx context.Context, repo *gogit.Repository, fs billy.Filesystem, config Config, provider ProviderConfig, targetBranch string) (upToDate bool, err error)
+	// PlanFiles writes and stages the configured files in memory without committing or pushing,
+	// reporting how each file compares to the target branch.
+	PlanFiles(ctx context.Context, repo *gogit.Repository, fs billy.Filesystem, config Config, targetBranch string) ([]FileChange, error)
+	// DiffFiles returns the unified diff the copy would produce, committing only to the in-memory clone.
+	DiffFiles(ctx context.Context, repo *gogit.Repository, fs billy.Filesystem, config Config, targetBranch string) (string, error)
 }
 
+// ChangeStatus describes how a copied file compares to the target repository.
+type ChangeStatus string
+
+const (
+	ChangeAdded     ChangeStatus = "added"
+	ChangeModified  ChangeStatus = "modified"
+	ChangeUnchanged ChangeStatus = "unchanged"
+)
+
+// FileChange is a single file BoneClone writes into a target repository.
+type FileChange struct {
+	Path   string
+	Status ChangeStatus
+}
+
+// Repository visibilities reported in GitRepository.Visibility.
+const (
+	VisibilityPublic   = "public"
+	VisibilityPrivate  = "private"
+	VisibilityInternal = "internal"
+)
+
+// GitRepository is a repository discovered by a provider. Only Name and Url are always set;
+// the remaining metadata is filled in when the provider's API reports it.
 type GitRepository struct {
+	// Name identifies the repository to its provider's PullRequestManager: usually the repository name,
+	// "Project/Repository" for Azure DevOps and "KEY/slug" for Bitbucket Server when listing every project.
 	Name string
 	Url  string
+	// Owner is the org, user, group path or Azure DevOps project containing the repository.
+	Owner string
+	// ID is the provider's identifier for the repository.
+	ID            string
+	DefaultBranch string
+	Archived      bool
+	Fork          bool
+	// Visibility is one of VisibilityPublic, VisibilityPrivate or VisibilityInternal, or empty when unknown.
+	Visibility string
+	Topics     []string
+	// LastActivity is when the repository was last pushed to or updated, zero when unknown.
+	LastActivity time.Time
 }
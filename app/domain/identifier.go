@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/knadh/koanf/v2"
+)
+
+// IdentifierProber is implemented by providers that can read a repository's identifier file through their
+// API. Repositories without the file, or whose file does not accept the skeleton, are then skipped without
+// being cloned.
+type IdentifierProber interface {
+	// ReadIdentifier returns the content of path on the repository's default branch,
+	// or ErrIdentifierNotFound when the file does not exist.
+	ReadIdentifier(ctx context.Context, repo GitRepository, path string) ([]byte, error)
+}
+
+// EvaluateIdentifier parses the content of an identifier file and reports whether it accepts the configured
+// skeleton. Files that are not valid YAML or do not match RemoteConfig accept nothing.
+func EvaluateIdentifier(content []byte, config Config) (bool, RemoteConfig) {
+	var rCfg RemoteConfig
+	k := koanf.NewWithConf(koanf.Conf{Delim: ".", StrictMerge: true})
+	if err := k.Load(rawbytes.Provider(content), yaml.Parser()); err != nil {
+		return false, RemoteConfig{}
+	}
+	if err := k.Unmarshal("", &rCfg); err != nil {
+		return false, RemoteConfig{}
+	}
+
+	skel := strings.TrimSpace(config.Identifier.Name)
+	if skel == "" {
+		return false, rCfg
+	}
+	for _, a := range rCfg.Accepts {
+		if strings.TrimSpace(a) == skel {
+			return true, rCfg
+		}
+	}
+	return false, rCfg
+}
+
+// probeIdentifier reads the identifier file through prober before anything is cloned. It reports handled
+// when the repository can be skipped: the file is missing or does not accept the skeleton. Accepted
+// repositories and probe failures are left to the processor, which validates the cloned repository.
+func probeIdentifier(ctx context.Context, prober IdentifierProber, repo GitRepository, config Config) (res RepoResult, handled bool) {
+	if prober == nil {
+		return RepoResult{}, false
+	}
+	content, err := prober.ReadIdentifier(ctx, repo, config.Identifier.Filename)
+	if errors.Is(err, ErrIdentifierNotFound) {
+		return RepoResult{Outcome: OutcomeNoIdentifier}, true
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Printf("could not probe identifier for %s, cloning instead: %v\n", repo.Url, err)
+		}
+		return RepoResult{}, false
+	}
+	if valid, remoteCfg := EvaluateIdentifier(content, config); !valid {
+		return RepoResult{Outcome: OutcomeNotAccepted, Remote: remoteCfg}, true
+	}
+	return RepoResult{}, false
+}
@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultConcurrency is the number of repositories processed at once when run.concurrency is not set.
+const DefaultConcurrency = 4
+
+// semaphore is a counting semaphore backed by a buffered channel.
+type semaphore chan struct{}
+
+func newSemaphore(n int) semaphore { return make(semaphore, n) }
+
+// tryAcquire takes a slot if one is free, without blocking.
+func (s semaphore) tryAcquire() bool {
+	select {
+	case s <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s semaphore) release() { <-s }
+
+// limiter enforces the per-provider and per-host concurrency limits on top of the worker pool size.
+type limiter struct {
+	providers map[int]semaphore
+	hosts     map[string]semaphore
+}
+
+func newLimiter(config Config) *limiter {
+	l := &limiter{providers: map[int]semaphore{}, hosts: map[string]semaphore{}}
+	for i, pp := range config.Providers {
+		if pp.Concurrency > 0 {
+			l.providers[i] = newSemaphore(pp.Concurrency)
+		}
+	}
+	for _, h := range config.Run.Hosts {
+		host := strings.ToLower(strings.TrimSpace(h.Host))
+		if host == "" || h.Concurrency <= 0 {
+			continue
+		}
+		l.hosts[host] = newSemaphore(h.Concurrency)
+	}
+	return l
+}
+
+// tryAcquire takes the job's provider and host slots if they are all free and returns the function releasing
+// them. Nothing is held when it reports false.
+func (l *limiter) tryAcquire(job repoJob) (func(), bool) {
+	var held []semaphore
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].release()
+		}
+	}
+
+	var wanted []semaphore
+	if s, ok := l.providers[job.providerIndex]; ok {
+		wanted = append(wanted, s)
+	}
+	if s, ok := l.hosts[repoHost(job.repo.Url)]; ok {
+		wanted = append(wanted, s)
+	}
+	for _, s := range wanted {
+		if !s.tryAcquire() {
+			release()
+			return nil, false
+		}
+		held = append(held, s)
+	}
+	return release, true
+}
+
+// jobQueue holds discovered repositories until a worker can run them. Workers only take a job once its
+// provider and host slots are held, so a saturated provider or host never ties up workers that could run
+// jobs for the others.
+type jobQueue struct {
+	limits *limiter
+
+	mu      sync.Mutex
+	pending []repoJob
+	closed  bool
+	// changed is closed and replaced whenever a job is added, slots are released or the queue is closed.
+	changed chan struct{}
+}
+
+func newJobQueue(limits *limiter) *jobQueue {
+	return &jobQueue{limits: limits, changed: make(chan struct{})}
+}
+
+// push adds a job to the end of the queue.
+func (q *jobQueue) push(job repoJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, job)
+	q.notify()
+}
+
+// close marks the queue as complete; next reports false once every pending job has been taken.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.notify()
+}
+
+// next blocks until the oldest job whose slots are free can be taken, and returns it with the function
+// releasing its slots. It reports false once the queue is closed and empty, or ctx is done.
+func (q *jobQueue) next(ctx context.Context) (repoJob, func(), bool) {
+	for {
+		if ctx.Err() != nil {
+			return repoJob{}, nil, false
+		}
+
+		q.mu.Lock()
+		for i, job := range q.pending {
+			if release, ok := q.limits.tryAcquire(job); ok {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				q.mu.Unlock()
+				return job, q.releaser(release), true
+			}
+		}
+		if q.closed && len(q.pending) == 0 {
+			q.mu.Unlock()
+			return repoJob{}, nil, false
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// releaser wraps release so waiting workers look for a runnable job again once the slots are free.
+func (q *jobQueue) releaser(release func()) func() {
+	return func() {
+		release()
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.notify()
+	}
+}
+
+// notify wakes every worker waiting in next. The caller must hold q.mu.
+func (q *jobQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// workerCount returns the size of the worker pool for the given config.
+func workerCount(config Config) int {
+	if config.Run.Concurrency > 0 {
+		return config.Run.Concurrency
+	}
+	return DefaultConcurrency
+}
+
+// repoHost returns the lower-cased host of a repository clone URL, or "" if it cannot be parsed.
+func repoHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -1,6 +1,7 @@
 package domain_test
 
 import (
+	"context"
 	"testing"
 
 	"go.iain.rocks/boneclone/app/domain"
@@ -14,7 +15,7 @@ func TestNewProcessorForConfig_SelectsProcessors(t *testing.T) {
 
 	p2 := domain.NewProcessorForConfig(domain.Config{Git: domain.GitConfig{PullRequest: true}}, nil, nil)
 	// prProcessor is unexported; assert behavior via Process()
-	if err := p2.Process(domain.GitRepository{}, domain.ProviderConfig{}, domain.Config{}); err == nil || err.Error() != "git ops not configured" {
+	if _, err := p2.Process(context.Background(), domain.GitRepository{}, domain.ProviderConfig{}, domain.Config{}); err == nil || err.Error() != "git ops not configured" {
 		t.Fatalf("expected PR processor to return 'git ops not configured', got: %v", err)
 	}
 }
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"context"
+	"errors"
 	"fmt"
 )
 
@@ -9,28 +11,36 @@ type Processor struct{ ops GitOperations }
 
 func NewProcessor(ops GitOperations) *Processor { return &Processor{ops: ops} }
 
-func (p *Processor) Process(repo GitRepository, pp ProviderConfig, config Config) error {
+func (p *Processor) Process(ctx context.Context, repo GitRepository, pp ProviderConfig, config Config) (RepoResult, error) {
 	if p.ops == nil {
-		return fmt.Errorf("git ops not configured")
+		return RepoResult{}, fmt.Errorf("git ops not configured")
 	}
 	fmt.Printf("repo: %s\n", repo.Url)
 
-	gitRepo, fs, err := p.ops.CloneGit(repo, pp)
+	gitRepo, fs, err := p.ops.CloneGit(ctx, repo, pp)
 	if err != nil {
-		return fmt.Errorf("clone: %w", err)
+		return RepoResult{}, stageError(StageClone, err)
 	}
 
-	valid, _, err := p.ops.IsValidForBoneClone(gitRepo, config)
+	valid, _, err := p.ops.IsValidForBoneClone(ctx, gitRepo, config)
+	if errors.Is(err, ErrIdentifierNotFound) {
+		return RepoResult{Outcome: OutcomeNoIdentifier}, nil
+	}
 	if err != nil {
-		return fmt.Errorf("validate: %w", err)
+		return RepoResult{}, stageError(StageValidate, err)
+	}
+	if !valid {
+		return RepoResult{Outcome: OutcomeNotAccepted}, nil
 	}
 
-	if valid {
-		tb := config.Git.TargetBranch
-		if err := p.ops.CopyFiles(gitRepo, fs, config, pp, tb); err != nil {
-			return fmt.Errorf("copy: %w", err)
-		}
+	tb := config.Git.TargetBranch
+	upToDate, err := p.ops.CopyFiles(ctx, gitRepo, fs, config, pp, tb)
+	if err != nil {
+		return RepoResult{}, stageError(StageCopy, err)
+	}
+	if upToDate {
+		return RepoResult{Outcome: OutcomeUpToDate}, nil
 	}
 
-	return nil
+	return RepoResult{Outcome: OutcomePushed}, nil
 }
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// InspectProcessor implements RepoProcessor for listing: clone -> read the identifier file.
+// It reports whether the repository accepts the configured skeleton and the reviewers it declares,
+// without copying any files.
+type InspectProcessor struct{ ops GitOperations }
+
+func NewInspectProcessor(ops GitOperations) *InspectProcessor { return &InspectProcessor{ops: ops} }
+
+func (p *InspectProcessor) Process(ctx context.Context, repo GitRepository, pp ProviderConfig, config Config) (RepoResult, error) {
+	if p.ops == nil {
+		return RepoResult{}, fmt.Errorf("git ops not configured")
+	}
+
+	gitRepo, _, err := p.ops.CloneGit(ctx, repo, pp)
+	if err != nil {
+		return RepoResult{}, stageError(StageClone, err)
+	}
+
+	valid, remoteCfg, err := p.ops.IsValidForBoneClone(ctx, gitRepo, config)
+	if errors.Is(err, ErrIdentifierNotFound) {
+		return RepoResult{Outcome: OutcomeNoIdentifier}, nil
+	}
+	if err != nil {
+		return RepoResult{}, stageError(StageValidate, err)
+	}
+	if !valid {
+		return RepoResult{Outcome: OutcomeNotAccepted, Remote: remoteCfg}, nil
+	}
+	return RepoResult{Outcome: OutcomeAccepted, Remote: remoteCfg}, nil
+}
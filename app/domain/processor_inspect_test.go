@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v6"
+)
+
+// fakeOpsInspect returns a fixed remote config from IsValidForBoneClone.
+type fakeOpsInspect struct {
+	fakeOps
+	remote RemoteConfig
+}
+
+func (f *fakeOpsInspect) IsValidForBoneClone(_ context.Context, _ *gogit.Repository, _ Config) (bool, RemoteConfig, error) {
+	return f.valid, f.remote, f.validErr
+}
+
+func TestInspectProcessor_Outcomes(t *testing.T) {
+	remote := RemoteConfig{Accepts: []string{"Skeleton"}, Reviewers: []string{"alice"}}
+	cases := []struct {
+		name string
+		ops  *fakeOpsInspect
+		want Outcome
+	}{
+		{name: "accepted", ops: &fakeOpsInspect{fakeOps: fakeOps{valid: true}, remote: remote}, want: OutcomeAccepted},
+		{name: "not accepted", ops: &fakeOpsInspect{remote: remote}, want: OutcomeNotAccepted},
+		{name: "no identifier", ops: &fakeOpsInspect{fakeOps: fakeOps{validErr: ErrIdentifierNotFound}}, want: OutcomeNoIdentifier},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := NewInspectProcessor(tc.ops).Process(context.Background(), GitRepository{}, ProviderConfig{}, Config{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Outcome != tc.want {
+				t.Fatalf("expected outcome %q, got %q", tc.want, res.Outcome)
+			}
+			if tc.want != OutcomeNoIdentifier && len(res.Remote.Reviewers) != 1 {
+				t.Fatalf("expected reviewers to be reported, got %+v", res.Remote)
+			}
+			if tc.ops.copyCalled {
+				t.Fatalf("expected CopyFiles not to be called")
+			}
+		})
+	}
+}
+
+func TestInspectProcessor_CloneError(t *testing.T) {
+	ops := &fakeOpsInspect{fakeOps: fakeOps{cloneErr: errors.New("boom")}}
+	_, err := NewInspectProcessor(ops).Process(context.Background(), GitRepository{}, ProviderConfig{}, Config{})
+	if err == nil || err.Error() != "clone: boom" {
+		t.Fatalf("expected clone stage error, got %v", err)
+	}
+}
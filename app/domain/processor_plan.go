@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PlanProcessor implements RepoProcessor for dry runs: clone -> validate -> copy in memory.
+// Nothing is pushed or opened as a pull request.
+type PlanProcessor struct {
+	ops  GitOperations
+	diff bool
+}
+
+func NewPlanProcessor(ops GitOperations) *PlanProcessor { return &PlanProcessor{ops: ops} }
+
+// NewDiffProcessor returns a PlanProcessor that also renders the unified diff of each repository's changes.
+func NewDiffProcessor(ops GitOperations) *PlanProcessor { return &PlanProcessor{ops: ops, diff: true} }
+
+func (p *PlanProcessor) Process(ctx context.Context, repo GitRepository, pp ProviderConfig, config Config) (RepoResult, error) {
+	if p.ops == nil {
+		return RepoResult{}, fmt.Errorf("git ops not configured")
+	}
+
+	gitRepo, fs, err := p.ops.CloneGit(ctx, repo, pp)
+	if err != nil {
+		return RepoResult{}, stageError(StageClone, err)
+	}
+
+	valid, _, err := p.ops.IsValidForBoneClone(ctx, gitRepo, config)
+	if errors.Is(err, ErrIdentifierNotFound) {
+		return RepoResult{Outcome: OutcomeNoIdentifier}, nil
+	}
+	if err != nil {
+		return RepoResult{}, stageError(StageValidate, err)
+	}
+	if !valid {
+		return RepoResult{Outcome: OutcomeNotAccepted}, nil
+	}
+
+	// Pull requests branch off the current HEAD, direct pushes land on the target branch.
+	tb := ""
+	if !config.Git.PullRequest {
+		tb = config.Git.TargetBranch
+	}
+	changes, err := p.ops.PlanFiles(ctx, gitRepo, fs, config, tb)
+	if err != nil {
+		return RepoResult{}, stageError(StageCopy, err)
+	}
+
+	res := RepoResult{Outcome: OutcomeUpToDate, Changes: changes}
+	for _, c := range changes {
+		if c.Status != ChangeUnchanged {
+			res.Outcome = OutcomeWouldUpdate
+			break
+		}
+	}
+	if p.diff && res.Outcome == OutcomeWouldUpdate {
+		if res.Diff, err = p.ops.DiffFiles(ctx, gitRepo, fs, config, tb); err != nil {
+			return RepoResult{}, stageError(StageCopy, err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	billy "github.com/go-git/go-billy/v6"
+	gogit "github.com/go-git/go-git/v6"
+)
+
+// fakeOpsPlan implements GitOperations for PlanProcessor tests and fails if CopyFiles is used.
+type fakeOpsPlan struct {
+	fakeOps
+	changes    []FileChange
+	planErr    error
+	planBranch string
+	diff       string
+	diffCalled bool
+}
+
+func (f *fakeOpsPlan) CopyFiles(_ context.Context, _ *gogit.Repository, _ billy.Filesystem, _ Config, _ ProviderConfig, _ string) (bool, error) {
+	f.copyCalled = true
+	return false, errors.New("CopyFiles must not be called when planning")
+}
+
+func (f *fakeOpsPlan) PlanFiles(_ context.Context, _ *gogit.Repository, _ billy.Filesystem, _ Config, targetBranch string) ([]FileChange, error) {
+	f.planBranch = targetBranch
+	return f.changes, f.planErr
+}
+
+func (f *fakeOpsPlan) DiffFiles(_ context.Context, _ *gogit.Repository, _ billy.Filesystem, _ Config, _ string) (string, error) {
+	f.diffCalled = true
+	return f.diff, nil
+}
+
+func TestPlanProcessor_ReportsChangesWithoutPushing(t *testing.T) {
+	ops := &fakeOpsPlan{
+		fakeOps: fakeOps{valid: true},
+		changes: []FileChange{{Path: "ci/a.sh", Status: ChangeAdded}, {Path: "ci/b.sh", Status: ChangeUnchanged}},
+	}
+	cfg := Config{Git: GitConfig{TargetBranch: "develop"}}
+
+	res, err := NewPlanProcessor(ops).Process(context.Background(), GitRepository{}, ProviderConfig{}, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ops.copyCalled {
+		t.Fatalf("expected CopyFiles not to be called")
+	}
+	if res.Outcome != OutcomeWouldUpdate || len(res.Changes) != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if ops.planBranch != "develop" {
+		t.Fatalf("expected plan against target branch 'develop', got %q", ops.planBranch)
+	}
+}
+
+func TestPlanProcessor_AllUnchanged_IsUpToDate(t *testing.T) {
+	ops := &fakeOpsPlan{
+		fakeOps: fakeOps{valid: true},
+		changes: []FileChange{{Path: "ci/a.sh", Status: ChangeUnchanged}},
+	}
+	cfg := Config{Git: GitConfig{PullRequest: true, TargetBranch: "develop"}}
+
+	res, err := NewPlanProcessor(ops).Process(context.Background(), GitRepository{}, ProviderConfig{}, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Outcome != OutcomeUpToDate {
+		t.Fatalf("expected outcome %q, got %q", OutcomeUpToDate, res.Outcome)
+	}
+	if ops.planBranch != "" {
+		t.Fatalf("expected PR mode to plan against HEAD, got %q", ops.planBranch)
+	}
+}
+
+func TestPlanProcessor_PlanError(t *testing.T) {
+	ops := &fakeOpsPlan{fakeOps: fakeOps{valid: true}, planErr: errors.New("planerr")}
+	_, err := NewPlanProcessor(ops).Process(context.Background(), GitRepository{}, ProviderConfig{}, Config{})
+	if err == nil || err.Error() != "copy: planerr" {
+		t.Fatalf("expected copy stage error, got %v", err)
+	}
+}
+
+func TestDiffProcessor_RendersDiffOnlyWhenChanged(t *testing.T) {
+	ops := &fakeOpsPlan{
+		fakeOps: fakeOps{valid: true},
+		changes: []FileChange{{Path: "ci/a.sh", Status: ChangeModified}},
+		diff:    "diff --git a/ci/a.sh b/ci/a.sh\n",
+	}
+	res, err := NewDiffProcessor(ops).Process(context.Background(), GitRepository{}, ProviderConfig{}, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Diff != ops.diff {
+		t.Fatalf("expected diff to be returned, got %q", res.Diff)
+	}
+
+	unchanged := &fakeOpsPlan{
+		fakeOps: fakeOps{valid: true},
+		changes: []FileChange{{Path: "ci/a.sh", Status: ChangeUnchanged}},
+	}
+	if _, err := NewDiffProcessor(unchanged).Process(context.Background(), GitRepository{}, ProviderConfig{}, Config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unchanged.diffCalled {
+		t.Fatalf("expected DiffFiles not to be called when nothing changed")
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -16,33 +17,40 @@ func newPRProcessor(ops GitOperations, pf ProviderFactory) *prProcessor {
 	return &prProcessor{ops: ops, newProvider: pf}
 }
 
-func (p *prProcessor) Process(repo GitRepository, pp ProviderConfig, config Config) error {
+func (p *prProcessor) Process(ctx context.Context, repo GitRepository, pp ProviderConfig, config Config) (RepoResult, error) {
 	if p.ops == nil {
-		return fmt.Errorf("git ops not configured")
+		return RepoResult{}, fmt.Errorf("git ops not configured")
 	}
 	if p.newProvider == nil {
-		return fmt.Errorf("provider factory not configured")
+		return RepoResult{}, fmt.Errorf("provider factory not configured")
 	}
 
-	gitRepo, fs, err := p.ops.CloneGit(repo, pp)
+	gitRepo, fs, err := p.ops.CloneGit(ctx, repo, pp)
 	if err != nil {
-		return fmt.Errorf("clone: %w", err)
+		return RepoResult{}, stageError(StageClone, err)
 	}
 
-	valid, remoteCfg, err := p.ops.IsValidForBoneClone(gitRepo, config)
+	valid, remoteCfg, err := p.ops.IsValidForBoneClone(ctx, gitRepo, config)
+	if errors.Is(err, ErrIdentifierNotFound) {
+		return RepoResult{Outcome: OutcomeNoIdentifier}, nil
+	}
 	if err != nil {
-		return fmt.Errorf("validate: %w", err)
+		return RepoResult{}, stageError(StageValidate, err)
 	}
 	if !valid {
-		return nil
+		return RepoResult{Outcome: OutcomeNotAccepted}, nil
 	}
 
 	// Generate a branch name for PR head
 	branchName := fmt.Sprintf("boneclone/update-%s", time.Now().UTC().Format("20060102-150405"))
 
 	// Copy files, commit, and push to the head branch
-	if err := p.ops.CopyFiles(gitRepo, fs, config, pp, branchName); err != nil {
-		return fmt.Errorf("copy: %w", err)
+	upToDate, err := p.ops.CopyFiles(ctx, gitRepo, fs, config, pp, branchName)
+	if err != nil {
+		return RepoResult{}, stageError(StageCopy, err)
+	}
+	if upToDate {
+		return RepoResult{Outcome: OutcomeUpToDate}, nil
 	}
 
 	// Create PR from head branch to base target branch
@@ -59,19 +67,19 @@ func (p *prProcessor) Process(repo GitRepository, pp ProviderConfig, config Conf
 
 	prov, err := p.newProvider(pp)
 	if err != nil {
-		return fmt.Errorf("create PR: %w", err)
+		return RepoResult{}, stageError(StagePullRequest, err)
 	}
 	if prMgr, ok := prov.(PullRequestManager); ok {
-		pr, err := prMgr.CreatePullRequest(context.Background(), repo.Name, base, branchName, prTitle, nil, "", DefaultPRBodyBuilder)
+		pr, err := prMgr.CreatePullRequest(ctx, repo.Name, base, branchName, prTitle, nil, "", DefaultPRBodyBuilder)
 		if err != nil {
-			return fmt.Errorf("create PR: %w", err)
+			return RepoResult{}, stageError(StagePullRequest, err)
 		}
 		// Attempt to assign reviewers from remote config; failures are ignored (silent)
 		if len(remoteCfg.Reviewers) > 0 {
-			_ = prMgr.AssignReviewers(context.Background(), repo.Name, pr, remoteCfg.Reviewers)
+			_ = prMgr.AssignReviewers(ctx, repo.Name, pr, remoteCfg.Reviewers)
 		}
-		return nil
+		return RepoResult{Outcome: OutcomePROpened, PR: pr}, nil
 	}
 
-	return fmt.Errorf("provider %s does not support pull requests", pp.Provider)
+	return RepoResult{}, stageError(StagePullRequest, fmt.Errorf("provider %s does not support pull requests", pp.Provider))
 }
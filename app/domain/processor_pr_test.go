@@ -6,7 +6,7 @@ import (
 	"strings"
 	"testing"
 
-	billy "github.com/go-git/go-billy/v5"
+	billy "github.com/go-git/go-billy/v6"
 	gogit "github.com/go-git/go-git/v6"
 )
 
@@ -17,32 +17,43 @@ type fakeOpsPR struct {
 	validErr   error
 	copyErr    error
 	copyCalled bool
+	upToDate   bool
 	lastBranch string
 }
 
-func (f *fakeOpsPR) CloneGit(repo GitRepository, config ProviderConfig) (*gogit.Repository, billy.Filesystem, error) {
+func (f *fakeOpsPR) CloneGit(_ context.Context, repo GitRepository, config ProviderConfig) (*gogit.Repository, billy.Filesystem, error) {
 	return nil, nil, f.cloneErr
 }
 
-func (f *fakeOpsPR) IsValidForBoneClone(repo *gogit.Repository, cfg Config) (bool, RemoteConfig, error) {
+func (f *fakeOpsPR) IsValidForBoneClone(_ context.Context, repo *gogit.Repository, cfg Config) (bool, RemoteConfig, error) {
 	return f.valid, RemoteConfig{}, f.validErr
 }
 
-func (f *fakeOpsPR) CopyFiles(repo *gogit.Repository, fs billy.Filesystem, cfg Config, pp ProviderConfig, targetBranch string) error {
+func (f *fakeOpsPR) CopyFiles(_ context.Context, repo *gogit.Repository, fs billy.Filesystem, cfg Config, pp ProviderConfig, targetBranch string) (bool, error) {
 	f.copyCalled = true
 	f.lastBranch = targetBranch
-	return f.copyErr
+	return f.upToDate, f.copyErr
+}
+
+func (f *fakeOpsPR) PlanFiles(_ context.Context, repo *gogit.Repository, fs billy.Filesystem, cfg Config, targetBranch string) ([]FileChange, error) {
+	return nil, nil
+}
+
+func (f *fakeOpsPR) DiffFiles(_ context.Context, repo *gogit.Repository, fs billy.Filesystem, cfg Config, targetBranch string) (string, error) {
+	return "", nil
 }
 
 // fake PR provider/manager implements both discovery and PR creation interfaces.
 type fakePRProviderManager struct {
-	called      bool
-	repo        string
-	base        string
-	head        string
+	called bool
+	repo   string
+	base   string
+	head   string
 }
 
-func (f *fakePRProviderManager) GetRepositories() (*[]GitRepository, error) { return &[]GitRepository{}, nil }
+func (f *fakePRProviderManager) GetRepositories(context.Context) (*[]GitRepository, error) {
+	return &[]GitRepository{}, nil
+}
 
 func (f *fakePRProviderManager) CreatePullRequest(_ context.Context, repo, baseBranch, headBranch, title string, filesChanged []string, originalAuthor string, buildBody PRBodyBuilder) (PRInfo, error) {
 	f.called = true
@@ -52,7 +63,9 @@ func (f *fakePRProviderManager) CreatePullRequest(_ context.Context, repo, baseB
 	return PRInfo{ID: 1, URL: "http://example/pr/1"}, nil
 }
 
-func (f *fakePRProviderManager) AssignReviewers(_ context.Context, _ string, _ PRInfo, _ []string) error { return nil }
+func (f *fakePRProviderManager) AssignReviewers(_ context.Context, _ string, _ PRInfo, _ []string) error {
+	return nil
+}
 
 func TestPRProcessor_ErrWhenOpsNil(t *testing.T) {
 	fakeProv := &fakePRProviderManager{}
@@ -63,7 +76,7 @@ func TestPRProcessor_ErrWhenOpsNil(t *testing.T) {
 	pp := ProviderConfig{}
 	cfg := Config{}
 
-	if err := p.Process(repo, pp, cfg); err == nil || !strings.Contains(err.Error(), "git ops not configured") {
+	if _, err := p.Process(context.Background(), repo, pp, cfg); err == nil || !strings.Contains(err.Error(), "git ops not configured") {
 		t.Fatalf("expected git ops not configured error, got %v", err)
 	}
 }
@@ -76,7 +89,7 @@ func TestPRProcessor_ErrWhenProviderFactoryNil(t *testing.T) {
 	pp := ProviderConfig{}
 	cfg := Config{}
 
-	if err := p.Process(repo, pp, cfg); err == nil || !strings.Contains(err.Error(), "provider factory not configured") {
+	if _, err := p.Process(context.Background(), repo, pp, cfg); err == nil || !strings.Contains(err.Error(), "provider factory not configured") {
 		t.Fatalf("expected provider factory not configured error, got %v", err)
 	}
 }
@@ -88,14 +101,14 @@ func TestPRProcessor_CloneAndValidateErrors(t *testing.T) {
 	// Clone error
 	ops := &fakeOpsPR{cloneErr: errors.New("boom")}
 	p := newPRProcessor(ops, pf)
-	if err := p.Process(GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err == nil || err.Error() != "clone: boom" {
+	if _, err := p.Process(context.Background(), GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err == nil || err.Error() != "clone: boom" {
 		t.Fatalf("expected clone error wrapping, got %v", err)
 	}
 
 	// Validate error
 	ops = &fakeOpsPR{validErr: errors.New("valerr")}
 	p = newPRProcessor(ops, pf)
-	if err := p.Process(GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err == nil || err.Error() != "validate: valerr" {
+	if _, err := p.Process(context.Background(), GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err == nil || err.Error() != "validate: valerr" {
 		t.Fatalf("expected validate error wrapping, got %v", err)
 	}
 }
@@ -106,7 +119,7 @@ func TestPRProcessor_NotValid_SkipsCopyAndPR(t *testing.T) {
 	ops := &fakeOpsPR{valid: false}
 	p := newPRProcessor(ops, pf)
 
-	if err := p.Process(GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err != nil {
+	if _, err := p.Process(context.Background(), GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 	if ops.copyCalled {
@@ -123,7 +136,7 @@ func TestPRProcessor_CopyError(t *testing.T) {
 	ops := &fakeOpsPR{valid: true, copyErr: errors.New("cperr")}
 	p := newPRProcessor(ops, pf)
 
-	if err := p.Process(GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err == nil || err.Error() != "copy: cperr" {
+	if _, err := p.Process(context.Background(), GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err == nil || err.Error() != "copy: cperr" {
 		t.Fatalf("expected copy error wrapping, got %v", err)
 	}
 	if fakeProv.called {
@@ -140,7 +153,7 @@ func TestPRProcessor_Success_UsesTargetBranch_AndCallsPRCreator(t *testing.T) {
 	repo := GitRepository{Name: "my-repo"}
 	cfg := Config{Git: GitConfig{TargetBranch: "develop"}}
 
-	if err := p.Process(repo, ProviderConfig{}, cfg); err != nil {
+	if _, err := p.Process(context.Background(), repo, ProviderConfig{}, cfg); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if !ops.copyCalled {
@@ -169,10 +182,44 @@ func TestPRProcessor_Success_DefaultsBaseToMain(t *testing.T) {
 	ops := &fakeOpsPR{valid: true}
 	p := newPRProcessor(ops, pf)
 
-	if err := p.Process(GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err != nil {
+	if _, err := p.Process(context.Background(), GitRepository{Name: "r"}, ProviderConfig{}, Config{}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if fakeProv.base != "main" {
 		t.Fatalf("expected default base 'main', got %q", fakeProv.base)
 	}
 }
+
+func TestPRProcessor_Success_ReportsPROpened(t *testing.T) {
+	fakeProv := &fakePRProviderManager{}
+	pf := func(pp ProviderConfig) (GitRepositoryProvider, error) { return fakeProv, nil }
+	p := newPRProcessor(&fakeOpsPR{valid: true}, pf)
+
+	res, err := p.Process(context.Background(), GitRepository{Name: "r"}, ProviderConfig{}, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Outcome != OutcomePROpened {
+		t.Fatalf("expected outcome %q, got %q", OutcomePROpened, res.Outcome)
+	}
+	if res.PR.URL != "http://example/pr/1" {
+		t.Fatalf("expected PR info to be returned, got %+v", res.PR)
+	}
+}
+
+func TestPRProcessor_UpToDate_SkipsPR(t *testing.T) {
+	fakeProv := &fakePRProviderManager{}
+	pf := func(pp ProviderConfig) (GitRepositoryProvider, error) { return fakeProv, nil }
+	p := newPRProcessor(&fakeOpsPR{valid: true, upToDate: true}, pf)
+
+	res, err := p.Process(context.Background(), GitRepository{Name: "r"}, ProviderConfig{}, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Outcome != OutcomeUpToDate {
+		t.Fatalf("expected outcome %q, got %q", OutcomeUpToDate, res.Outcome)
+	}
+	if fakeProv.called {
+		t.Fatalf("expected PR creation not to be called when nothing changed")
+	}
+}
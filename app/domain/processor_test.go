@@ -1,10 +1,11 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"testing"
 
-	billy "github.com/go-git/go-billy/v5"
+	billy "github.com/go-git/go-billy/v6"
 	gogit "github.com/go-git/go-git/v6"
 )
 
@@ -14,17 +15,26 @@ type fakeOps struct {
 	validErr   error
 	copyErr    error
 	copyCalled bool
+	upToDate   bool
 }
 
-func (f *fakeOps) CloneGit(repo GitRepository, config ProviderConfig) (*gogit.Repository, billy.Filesystem, error) {
+func (f *fakeOps) CloneGit(_ context.Context, repo GitRepository, config ProviderConfig) (*gogit.Repository, billy.Filesystem, error) {
 	return nil, nil, f.cloneErr
 }
-func (f *fakeOps) IsValidForBoneClone(repo *gogit.Repository, config Config) (bool, RemoteConfig, error) {
+func (f *fakeOps) IsValidForBoneClone(_ context.Context, repo *gogit.Repository, config Config) (bool, RemoteConfig, error) {
 	return f.valid, RemoteConfig{}, f.validErr
 }
-func (f *fakeOps) CopyFiles(repo *gogit.Repository, fs billy.Filesystem, cfg Config, pp ProviderConfig, targetBranch string) error {
+func (f *fakeOps) CopyFiles(_ context.Context, repo *gogit.Repository, fs billy.Filesystem, cfg Config, pp ProviderConfig, targetBranch string) (bool, error) {
 	f.copyCalled = true
-	return f.copyErr
+	return f.upToDate, f.copyErr
+}
+
+func (f *fakeOps) PlanFiles(_ context.Context, repo *gogit.Repository, fs billy.Filesystem, cfg Config, targetBranch string) ([]FileChange, error) {
+	return nil, nil
+}
+
+func (f *fakeOps) DiffFiles(_ context.Context, repo *gogit.Repository, fs billy.Filesystem, cfg Config, targetBranch string) (string, error) {
+	return "", nil
 }
 
 func TestProcessor_Process_CloneError(t *testing.T) {
@@ -34,7 +44,7 @@ func TestProcessor_Process_CloneError(t *testing.T) {
 	ops := &fakeOps{cloneErr: errors.New("boom")}
 	p := NewProcessor(ops)
 
-	err := p.Process(repo, pp, cfg)
+	_, err := p.Process(context.Background(), repo, pp, cfg)
 	if err == nil || err.Error() != "clone: boom" {
 		t.Fatalf("expected clone error wrapping, got: %v", err)
 	}
@@ -47,7 +57,7 @@ func TestProcessor_Process_ValidateError(t *testing.T) {
 	ops := &fakeOps{validErr: errors.New("valerr")}
 	p := NewProcessor(ops)
 
-	err := p.Process(repo, pp, cfg)
+	_, err := p.Process(context.Background(), repo, pp, cfg)
 	if err == nil || err.Error() != "validate: valerr" {
 		t.Fatalf("expected validate error wrapping, got: %v", err)
 	}
@@ -60,7 +70,7 @@ func TestProcessor_Process_NotValid_SkipsCopy(t *testing.T) {
 	ops := &fakeOps{valid: false}
 	p := NewProcessor(ops)
 
-	if err := p.Process(repo, pp, cfg); err != nil {
+	if _, err := p.Process(context.Background(), repo, pp, cfg); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 	if ops.copyCalled {
@@ -75,7 +85,7 @@ func TestProcessor_Process_Valid_CopySuccess(t *testing.T) {
 	ops := &fakeOps{valid: true}
 	p := NewProcessor(ops)
 
-	if err := p.Process(repo, pp, cfg); err != nil {
+	if _, err := p.Process(context.Background(), repo, pp, cfg); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 	if !ops.copyCalled {
@@ -90,8 +100,43 @@ func TestProcessor_Process_CopyError(t *testing.T) {
 	ops := &fakeOps{valid: true, copyErr: errors.New("cperr")}
 	p := NewProcessor(ops)
 
-	err := p.Process(repo, pp, cfg)
+	_, err := p.Process(context.Background(), repo, pp, cfg)
 	if err == nil || err.Error() != "copy: cperr" {
 		t.Fatalf("expected copy error wrapping, got: %v", err)
 	}
 }
+
+func TestProcessor_Process_Outcomes(t *testing.T) {
+	repo := GitRepository{Url: "https://example.com/repo.git"}
+	cases := []struct {
+		name string
+		ops  *fakeOps
+		want Outcome
+	}{
+		{name: "no identifier", ops: &fakeOps{validErr: ErrIdentifierNotFound}, want: OutcomeNoIdentifier},
+		{name: "not accepted", ops: &fakeOps{valid: false}, want: OutcomeNotAccepted},
+		{name: "up to date", ops: &fakeOps{valid: true, upToDate: true}, want: OutcomeUpToDate},
+		{name: "pushed", ops: &fakeOps{valid: true}, want: OutcomePushed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := NewProcessor(tc.ops).Process(context.Background(), repo, ProviderConfig{}, Config{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Outcome != tc.want {
+				t.Fatalf("expected outcome %q, got %q", tc.want, res.Outcome)
+			}
+		})
+	}
+}
+
+func TestProcessor_Process_ErrorCarriesStage(t *testing.T) {
+	ops := &fakeOps{valid: true, copyErr: errors.New("cperr")}
+	_, err := NewProcessor(ops).Process(context.Background(), GitRepository{}, ProviderConfig{}, Config{})
+
+	var se *StageError
+	if !errors.As(err, &se) || se.Stage != StageCopy {
+		t.Fatalf("expected StageError with stage %q, got %v", StageCopy, err)
+	}
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrIdentifierNotFound is returned by GitOperations.IsValidForBoneClone when the repository has no identifier file.
+var ErrIdentifierNotFound = errors.New("identifier file not found")
+
+// Outcome is the final state of a single repository after a run.
+type Outcome string
+
+const (
+	OutcomeNotAccepted  Outcome = "not-accepted"
+	OutcomeNoIdentifier Outcome = "no-identifier"
+	OutcomeUpToDate     Outcome = "up-to-date"
+	OutcomePushed       Outcome = "pushed"
+	OutcomePROpened     Outcome = "pr-opened"
+	OutcomeWouldUpdate  Outcome = "would-update"
+	OutcomeAccepted     Outcome = "accepted"
+	OutcomeFailed       Outcome = "failed"
+)
+
+// Stage names the step of the pipeline where a failure happened.
+type Stage string
+
+const (
+	StageProvider    Stage = "provider"
+	StageDiscovery   Stage = "discovery"
+	StageClone       Stage = "clone"
+	StageValidate    Stage = "validate"
+	StageCopy        Stage = "copy"
+	StagePullRequest Stage = "pull-request"
+)
+
+// StageError wraps an error with the pipeline stage it came from.
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *StageError) Error() string { return fmt.Sprintf("%s: %v", e.Stage, e.Err) }
+
+func (e *StageError) Unwrap() error { return e.Err }
+
+func stageError(stage Stage, err error) error { return &StageError{Stage: stage, Err: err} }
+
+// RepoResult is the outcome of processing a single repository.
+// Processors fill in Outcome and PR; Run fills in the repository, provider and failure details.
+type RepoResult struct {
+	Repo     GitRepository
+	Provider string
+	Outcome  Outcome
+	Stage    Stage
+	Err      error
+	PR       PRInfo
+	Changes  []FileChange
+	Diff     string
+	Remote   RemoteConfig
+	Retries  int
+}
+
+// ProviderFailure records a provider that could not be created or could not list its repositories.
+type ProviderFailure struct {
+	Provider string
+	Org      string
+	Stage    Stage
+	Err      error
+}
+
+// RunStatus summarizes a RunReport for exit code purposes.
+type RunStatus int
+
+const (
+	// RunSucceeded means nothing failed.
+	RunSucceeded RunStatus = iota
+	// RunPartiallyFailed means some repositories or providers failed while others succeeded.
+	RunPartiallyFailed
+	// RunFailed means there were failures and nothing succeeded.
+	RunFailed
+)
+
+// RunReport collects the results of a run.
+type RunReport struct {
+	Results          []RepoResult
+	ProviderFailures []ProviderFailure
+}
+
+// Count returns the number of repositories that finished with the given outcome.
+func (r RunReport) Count(outcome Outcome) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+// Retries returns the total number of retries across all repositories.
+func (r RunReport) Retries() int {
+	n := 0
+	for _, res := range r.Results {
+		n += res.Retries
+	}
+	return n
+}
+
+// Failures returns the number of failed repositories and providers.
+func (r RunReport) Failures() int {
+	return r.Count(OutcomeFailed) + len(r.ProviderFailures)
+}
+
+// Status reports whether the run succeeded, partially failed or failed completely.
+func (r RunReport) Status() RunStatus {
+	failures := r.Failures()
+	if failures == 0 {
+		return RunSucceeded
+	}
+	if len(r.Results)-r.Count(OutcomeFailed) == 0 {
+		return RunFailed
+	}
+	return RunPartiallyFailed
+}
+
+// sortResults orders results by provider then repository URL so reports are stable between runs.
+func (r *RunReport) sortResults() {
+	sort.SliceStable(r.Results, func(i, j int) bool {
+		if r.Results[i].Provider != r.Results[j].Provider {
+			return r.Results[i].Provider < r.Results[j].Provider
+		}
+		return r.Results[i].Repo.Url < r.Results[j].Repo.Url
+	})
+}
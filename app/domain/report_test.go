@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunReport_Status(t *testing.T) {
+	ok := RepoResult{Outcome: OutcomePushed}
+	failed := RepoResult{Outcome: OutcomeFailed, Stage: StageClone, Err: errors.New("boom")}
+	provFail := ProviderFailure{Provider: "github", Stage: StageDiscovery, Err: errors.New("boom")}
+
+	cases := []struct {
+		name   string
+		report RunReport
+		want   RunStatus
+	}{
+		{name: "empty", report: RunReport{}, want: RunSucceeded},
+		{name: "all ok", report: RunReport{Results: []RepoResult{ok, ok}}, want: RunSucceeded},
+		{name: "some failed", report: RunReport{Results: []RepoResult{ok, failed}}, want: RunPartiallyFailed},
+		{name: "provider failed with other repos ok", report: RunReport{Results: []RepoResult{ok}, ProviderFailures: []ProviderFailure{provFail}}, want: RunPartiallyFailed},
+		{name: "all failed", report: RunReport{Results: []RepoResult{failed, failed}}, want: RunFailed},
+		{name: "only provider failures", report: RunReport{ProviderFailures: []ProviderFailure{provFail}}, want: RunFailed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.report.Status(); got != tc.want {
+				t.Fatalf("expected status %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStageError_MessageAndUnwrap(t *testing.T) {
+	inner := errors.New("boom")
+	err := stageError(StageClone, inner)
+	if err.Error() != "clone: boom" {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+	if !errors.Is(err, inner) {
+		t.Fatalf("expected StageError to unwrap to the inner error")
+	}
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Retry defaults used when retry values are not configured.
+const (
+	DefaultRetryAttempts     = 3
+	DefaultRetryInitialDelay = time.Second
+	DefaultRetryMaxDelay     = 30 * time.Second
+)
+
+// RetryConfig controls exponential backoff for transient clone, push and provider API failures.
+// Attempts is the total number of tries including the first; 1 disables retries.
+type RetryConfig struct {
+	Attempts     int           `koanf:"attempts"`
+	InitialDelay time.Duration `koanf:"initialDelay"`
+	MaxDelay     time.Duration `koanf:"maxDelay"`
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.Attempts <= 0 {
+		c.Attempts = DefaultRetryAttempts
+	}
+	if c.InitialDelay <= 0 {
+		c.InitialDelay = DefaultRetryInitialDelay
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = DefaultRetryMaxDelay
+	}
+	return c
+}
+
+// transientError marks an error as worth retrying, optionally no sooner than after.
+type transientError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+
+func (e *transientError) Unwrap() error { return e.err }
+
+// Transient marks err as transient so Retry will try the operation again. A nil err stays nil.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// TransientAfter marks err as transient and asks Retry to wait at least d before the next attempt,
+// e.g. until a provider's rate limit resets. The wait is not capped by RetryConfig.MaxDelay. A nil err stays nil.
+func TransientAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err, after: d}
+}
+
+// IsTransientStatus reports whether an HTTP status code indicates a temporary server-side condition
+// (408, 429 or any 5xx). Git transports and provider APIs share it to classify their errors.
+func IsTransientStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= http.StatusInternalServerError
+}
+
+// IsTransient reports whether err, or any error it wraps, was marked with Transient.
+func IsTransient(err error) bool {
+	var te *transientError
+	return errors.As(err, &te)
+}
+
+type retryConfigKey struct{}
+
+type retryCounterKey struct{}
+
+// WithRetryConfig returns a context carrying the retry policy used by Retry.
+func WithRetryConfig(ctx context.Context, cfg RetryConfig) context.Context {
+	return context.WithValue(ctx, retryConfigKey{}, cfg)
+}
+
+// withRetryCounter returns a context whose retries are counted in the returned counter.
+func withRetryCounter(ctx context.Context) (context.Context, *atomic.Int64) {
+	counter := &atomic.Int64{}
+	return context.WithValue(ctx, retryCounterKey{}, counter), counter
+}
+
+// Retry runs fn until it succeeds, returns a non-transient error, ctx is done or the attempts
+// from the context's RetryConfig are used up. Delays grow exponentially from InitialDelay up to MaxDelay.
+// Each retry is logged with op and counted against the context's retry counter, if any.
+func Retry(ctx context.Context, op string, fn func(context.Context) error) error {
+	cfg, _ := ctx.Value(retryConfigKey{}).(RetryConfig)
+	cfg = cfg.withDefaults()
+	counter, _ := ctx.Value(retryCounterKey{}).(*atomic.Int64)
+
+	delay := cfg.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !IsTransient(err) || attempt >= cfg.Attempts {
+			return err
+		}
+
+		wait := backoff(delay)
+		var te *transientError
+		if errors.As(err, &te) && te.after > wait {
+			wait = te.after
+		}
+		fmt.Printf("retrying %s in %s (attempt %d/%d): %v\n", op, wait, attempt+1, cfg.Attempts, err)
+		if counter != nil {
+			counter.Add(1)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		delay = min(delay*2, cfg.MaxDelay)
+	}
+}
+
+// backoff adds up to 20% jitter to delay so concurrent workers do not retry in lockstep.
+func backoff(delay time.Duration) time.Duration {
+	return delay + rand.N(delay/5+1)
+}
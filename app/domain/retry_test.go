@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func fastRetry(attempts int) context.Context {
+	return WithRetryConfig(context.Background(), RetryConfig{Attempts: attempts, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond})
+}
+
+func TestRetry_RetriesTransientErrors(t *testing.T) {
+	ctx, counter := withRetryCounter(fastRetry(3))
+	calls := 0
+	err := Retry(ctx, "op", func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return Transient(errors.New("flaky"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+	if got := counter.Load(); got != 2 {
+		t.Fatalf("expected 2 retries counted, got %d", got)
+	}
+}
+
+func TestRetry_StopsOnPermanentError(t *testing.T) {
+	permanent := errors.New("not found")
+	calls := 0
+	err := Retry(fastRetry(5), "op", func(context.Context) error {
+		calls++
+		return permanent
+	})
+	if !errors.Is(err, permanent) {
+		t.Fatalf("expected permanent error, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single call, got %d", calls)
+	}
+}
+
+func TestRetry_GivesUpAfterAttempts(t *testing.T) {
+	calls := 0
+	err := Retry(fastRetry(2), "op", func(context.Context) error {
+		calls++
+		return Transient(errors.New("flaky"))
+	})
+	if !IsTransient(err) {
+		t.Fatalf("expected last transient error, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestRetry_StopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(WithRetryConfig(context.Background(), RetryConfig{Attempts: 5, InitialDelay: time.Hour}))
+	calls := 0
+	err := Retry(ctx, "op", func(context.Context) error {
+		calls++
+		cancel()
+		return Transient(errors.New("flaky"))
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single call, got %d", calls)
+	}
+}
+
+func TestRetry_WaitsForTransientAfter(t *testing.T) {
+	calls := 0
+	start := time.Now()
+	err := Retry(fastRetry(2), "op", func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return TransientAfter(errors.New("rate limited"), 50*time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected to wait for the rate limit hint, waited %s", elapsed)
+	}
+}
+
+func TestIsTransientStatus(t *testing.T) {
+	for code, want := range map[int]bool{200: false, 401: false, 404: false, 408: true, 429: true, 500: true, 503: true} {
+		if got := IsTransientStatus(code); got != want {
+			t.Errorf("IsTransientStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
@@ -2,43 +2,190 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 )
 
 type ProviderFactory func(ProviderConfig) (GitRepositoryProvider, error)
 
 type RepoProcessor interface {
-	Process(repo GitRepository, provider ProviderConfig, config Config) error
+	Process(ctx context.Context, repo GitRepository, provider ProviderConfig, config Config) (RepoResult, error)
 }
 
-func Run(_ context.Context, config Config, newProvider ProviderFactory, processor RepoProcessor) error {
+// reportCollector gathers results from concurrent workers into a RunReport.
+type reportCollector struct {
+	mu     sync.Mutex
+	report RunReport
+}
+
+func (c *reportCollector) addResult(res RepoResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.report.Results = append(c.report.Results, res)
+}
+
+func (c *reportCollector) addProviderFailure(pp ProviderConfig, stage Stage, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.report.ProviderFailures = append(c.report.ProviderFailures, ProviderFailure{Provider: pp.Provider, Org: pp.Org, Stage: stage, Err: err})
+}
+
+// repoJob is a single repository queued for the worker pool.
+type repoJob struct {
+	repo          GitRepository
+	provider      ProviderConfig
+	providerIndex int
+	// prober reads the identifier file before cloning when the provider supports it.
+	prober IdentifierProber
+}
+
+// Run discovers repositories from every configured provider and processes them with a bounded
+// worker pool. The pool size is run.concurrency; per-provider and per-host limits are applied on top.
+// Canceling ctx stops discovery and queuing of new repositories and aborts in-flight work.
+// The returned report holds one result per processed repository; the error is only set when ctx ended the run.
+func Run(ctx context.Context, config Config, newProvider ProviderFactory, processor RepoProcessor) (RunReport, error) {
+	if config.Run.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Run.Timeout)
+		defer cancel()
+	}
+
+	ctx = WithRetryConfig(ctx, config.Retry)
+
+	targets, err := newTargetMatcher(config.Run.Targets)
+	if err != nil {
+		return RunReport{}, err
+	}
+
+	queue := newJobQueue(newLimiter(config))
+	collector := &reportCollector{}
+
 	var wg sync.WaitGroup
+	for range workerCount(config) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, release, ok := queue.next(ctx)
+				if !ok {
+					return
+				}
+				processJob(ctx, job, release, processor, config, collector)
+			}
+		}()
+	}
 
-	for _, pp := range config.Providers {
-		provider, err := newProvider(pp)
-		if err != nil {
-			fmt.Printf("error creating provider %s: %v\n", pp.Provider, err)
-			continue
-		}
+	enqueueRepositories(ctx, config, newProvider, targets, queue, collector)
+	queue.close()
+
+	wg.Wait()
+	collector.report.sortResults()
+	return collector.report, ctx.Err()
+}
 
-		repositories, err := provider.GetRepositories()
-		if err != nil {
-			fmt.Printf("error listing repositories for provider %s: %v\n", pp.Provider, err)
+// enqueueRepositories lists every selected provider's repositories and queues those matching the
+// run targets, stopping once ctx is done. Entries with several orgs or users are listed namespace by
+// namespace, and a repository reachable through more than one of them is only queued once.
+func enqueueRepositories(ctx context.Context, config Config, newProvider ProviderFactory, targets *targetMatcher, queue *jobQueue, collector *reportCollector) {
+	for i, entry := range config.Providers {
+		if !targets.provider(entry) {
 			continue
 		}
 
-		for _, repo := range *repositories {
-			wg.Add(1)
-			go func(repo GitRepository, pp ProviderConfig) {
-				defer wg.Done()
-				if err := processor.Process(repo, pp, config); err != nil {
-					fmt.Printf("error processing repo %s: %v\n", repo.Url, err)
+		seen := map[string]struct{}{}
+		for _, pp := range entry.Namespaces() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			provider, err := newProvider(pp)
+			if err != nil {
+				fmt.Printf("error creating provider %s: %v\n", pp.Provider, err)
+				collector.addProviderFailure(pp, StageProvider, err)
+				continue
+			}
+
+			var prober IdentifierProber
+			if pp.ProbesIdentifier() {
+				prober, _ = provider.(IdentifierProber)
+			}
+			repositories, err := discoverRepositories(ctx, provider, pp, config.Identifier.Filename)
+			if err != nil {
+				fmt.Printf("error listing repositories for provider %s: %v\n", pp.Provider, err)
+				collector.addProviderFailure(pp, StageDiscovery, err)
+				continue
+			}
+
+			for _, repo := range *repositories {
+				key := repoKey(repo)
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				if !targets.repo(repo) {
+					continue
+				}
+				if ctx.Err() != nil {
+					return
 				}
-			}(repo, pp)
+				queue.push(repoJob{repo: repo, provider: pp, providerIndex: i, prober: prober})
+			}
 		}
 	}
+}
 
-	wg.Wait()
-	return nil
+// discoverRepositories returns the provider's repositories, using code search for the identifier file when the
+// entry asks for it and the provider supports it, and listing every repository otherwise or when search fails.
+func discoverRepositories(ctx context.Context, provider GitRepositoryProvider, pp ProviderConfig, identifier string) (*[]GitRepository, error) {
+	if searcher, ok := provider.(RepositorySearcher); ok && strings.EqualFold(pp.Discovery, DiscoverySearch) {
+		repositories, err := searcher.SearchRepositories(ctx, identifier)
+		if err == nil || ctx.Err() != nil {
+			return repositories, err
+		}
+		fmt.Printf("code search unavailable for provider %s %s, listing every repository: %v\n", pp.Provider, pp.Org, err)
+	}
+	return provider.GetRepositories(ctx)
+}
+
+// repoKey identifies a repository by its clone URL, ignoring case and a trailing .git.
+func repoKey(repo GitRepository) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimRight(repo.Url, "/")), ".git")
+}
+
+// processJob runs the processor for a single repository within its per-repository timeout, then releases the
+// concurrency slots the job was taken with.
+func processJob(ctx context.Context, job repoJob, release func(), processor RepoProcessor, config Config, collector *reportCollector) {
+	defer release()
+
+	if ctx.Err() != nil {
+		return
+	}
+	if config.Run.RepoTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Run.RepoTimeout)
+		defer cancel()
+	}
+
+	ctx, retries := withRetryCounter(ctx)
+	// Repositories the provider shows cannot accept the skeleton are skipped without cloning.
+	var err error
+	res, handled := probeIdentifier(ctx, job.prober, job.repo, config)
+	if !handled {
+		res, err = processor.Process(ctx, job.repo, job.provider, config)
+	}
+	res.Repo = job.repo
+	res.Provider = job.provider.Provider
+	res.Retries = int(retries.Load())
+	if err != nil {
+		fmt.Printf("error processing repo %s: %v\n", job.repo.Url, err)
+		res.Outcome = OutcomeFailed
+		res.Err = err
+		var se *StageError
+		if errors.As(err, &se) {
+			res.Stage = se.Stage
+		}
+	}
+	collector.addResult(res)
 }
@@ -3,9 +3,11 @@ package domain
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sort"
 	"sync"
 	"testing"
+	"time"
 )
 
 type fakeProvider struct {
@@ -13,7 +15,7 @@ type fakeProvider struct {
 	err   error
 }
 
-func (f *fakeProvider) GetRepositories() (*[]GitRepository, error) {
+func (f *fakeProvider) GetRepositories(context.Context) (*[]GitRepository, error) {
 	if f.err != nil {
 		return nil, f.err
 	}
@@ -31,11 +33,11 @@ type procCall struct {
 	config   Config
 }
 
-func (p *recordingProcessor) Process(repo GitRepository, provider ProviderConfig, config Config) error {
+func (p *recordingProcessor) Process(_ context.Context, repo GitRepository, provider ProviderConfig, config Config) (RepoResult, error) {
 	p.mu.Lock()
 	p.calls = append(p.calls, procCall{repo: repo, provider: provider, config: config})
 	p.mu.Unlock()
-	return nil
+	return RepoResult{}, nil
 }
 
 // helper to build a ProviderFactory that interprets ProviderConfig.Provider values
@@ -74,7 +76,7 @@ func TestRun_ProcessesAllRepos(t *testing.T) {
 
 	rp := &recordingProcessor{}
 
-	if err := Run(context.Background(), cfg, testFactory(), rp); err != nil {
+	if _, err := Run(context.Background(), cfg, testFactory(), rp); err != nil {
 		t.Fatalf("Run returned error: %v", err)
 	}
 
@@ -111,7 +113,7 @@ func TestRun_SkipsOnFactoryError(t *testing.T) {
 
 	rp := &recordingProcessor{}
 
-	if err := Run(context.Background(), cfg, testFactory(), rp); err != nil {
+	if _, err := Run(context.Background(), cfg, testFactory(), rp); err != nil {
 		t.Fatalf("Run returned error: %v", err)
 	}
 
@@ -137,7 +139,7 @@ func TestRun_SkipsOnListError(t *testing.T) {
 
 	rp := &recordingProcessor{}
 
-	if err := Run(context.Background(), cfg, testFactory(), rp); err != nil {
+	if _, err := Run(context.Background(), cfg, testFactory(), rp); err != nil {
 		t.Fatalf("Run returned error: %v", err)
 	}
 
@@ -152,3 +154,457 @@ func TestRun_SkipsOnListError(t *testing.T) {
 		}
 	}
 }
+
+// concurrencyProcessor records the highest number of Process calls in flight at once.
+type concurrencyProcessor struct {
+	mu      sync.Mutex
+	current int
+	max     int
+	calls   int
+}
+
+func (p *concurrencyProcessor) Process(_ context.Context, repo GitRepository, provider ProviderConfig, config Config) (RepoResult, error) {
+	p.mu.Lock()
+	p.current++
+	p.calls++
+	if p.current > p.max {
+		p.max = p.current
+	}
+	p.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	p.mu.Lock()
+	p.current--
+	p.mu.Unlock()
+	return RepoResult{}, nil
+}
+
+// manyReposFactory returns a factory whose providers each list n repos under the given host.
+func manyReposFactory(n int, host string) ProviderFactory {
+	return func(pc ProviderConfig) (GitRepositoryProvider, error) {
+		repos := make([]GitRepository, 0, n)
+		for i := range n {
+			repos = append(repos, GitRepository{Name: fmt.Sprintf("%s-%d", pc.Org, i), Url: fmt.Sprintf("https://%s/%s/%d", host, pc.Org, i)})
+		}
+		return &fakeProvider{repos: &repos}, nil
+	}
+}
+
+func TestRun_RespectsGlobalConcurrency(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{{Provider: "ok", Org: "one"}},
+		Run:       RunConfig{Concurrency: 3},
+	}
+	cp := &concurrencyProcessor{}
+
+	if _, err := Run(context.Background(), cfg, manyReposFactory(20, "example.com"), cp); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if cp.calls != 20 {
+		t.Fatalf("expected 20 Process calls, got %d", cp.calls)
+	}
+	if cp.max > 3 {
+		t.Fatalf("expected at most 3 concurrent Process calls, got %d", cp.max)
+	}
+}
+
+func TestRun_RespectsProviderConcurrency(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{{Provider: "ok", Org: "one", Concurrency: 1}},
+		Run:       RunConfig{Concurrency: 5},
+	}
+	cp := &concurrencyProcessor{}
+
+	if _, err := Run(context.Background(), cfg, manyReposFactory(6, "example.com"), cp); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if cp.calls != 6 {
+		t.Fatalf("expected 6 Process calls, got %d", cp.calls)
+	}
+	if cp.max != 1 {
+		t.Fatalf("expected provider limit of 1 to serialize processing, got max %d", cp.max)
+	}
+}
+
+func TestRun_RespectsHostConcurrency(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{{Provider: "ok", Org: "one"}, {Provider: "ok", Org: "two"}},
+		Run: RunConfig{
+			Concurrency: 8,
+			Hosts:       []HostLimit{{Host: "Git.Example.com", Concurrency: 2}},
+		},
+	}
+	cp := &concurrencyProcessor{}
+
+	if _, err := Run(context.Background(), cfg, manyReposFactory(5, "git.example.com"), cp); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if cp.calls != 10 {
+		t.Fatalf("expected 10 Process calls, got %d", cp.calls)
+	}
+	if cp.max > 2 {
+		t.Fatalf("expected at most 2 concurrent Process calls for the host, got %d", cp.max)
+	}
+}
+
+// gatedProcessor holds the slow org's repositories until every repository of the fast org has been processed.
+type gatedProcessor struct {
+	mu      sync.Mutex
+	fast    int
+	pending int
+	done    chan struct{}
+}
+
+func (p *gatedProcessor) Process(ctx context.Context, _ GitRepository, provider ProviderConfig, _ Config) (RepoResult, error) {
+	if provider.Org == "slow" {
+		select {
+		case <-p.done:
+			return RepoResult{}, nil
+		case <-ctx.Done():
+			return RepoResult{}, ctx.Err()
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fast++
+	if p.fast == p.pending {
+		close(p.done)
+	}
+	return RepoResult{}, nil
+}
+
+func TestRun_SaturatedProviderDoesNotBlockOthers(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{{Provider: "ok", Org: "slow", Concurrency: 1}, {Provider: "ok", Org: "fast", Concurrency: 2}},
+		Run:       RunConfig{Concurrency: 2},
+	}
+	gp := &gatedProcessor{pending: 3, done: make(chan struct{})}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	report, err := Run(ctx, cfg, manyReposFactory(3, "example.com"), gp)
+	if err != nil {
+		t.Fatalf("expected the fast provider to run while the slow one is saturated, got %v", err)
+	}
+	if len(report.Results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(report.Results))
+	}
+}
+
+func TestRun_CancelledContext_ProcessesNothing(t *testing.T) {
+	cfg := Config{Providers: []ProviderConfig{{Provider: "ok", Org: "one"}}}
+	rp := &recordingProcessor{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Run(ctx, cfg, testFactory(), rp)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(rp.calls) != 0 {
+		t.Fatalf("expected no Process calls after cancellation, got %d", len(rp.calls))
+	}
+}
+
+// deadlineProcessor records whether each Process call received a context with a deadline.
+type deadlineProcessor struct {
+	mu        sync.Mutex
+	deadlines []bool
+}
+
+func (p *deadlineProcessor) Process(ctx context.Context, _ GitRepository, _ ProviderConfig, _ Config) (RepoResult, error) {
+	_, ok := ctx.Deadline()
+	p.mu.Lock()
+	p.deadlines = append(p.deadlines, ok)
+	p.mu.Unlock()
+	return RepoResult{}, nil
+}
+
+func TestRun_AppliesRepoTimeout(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{{Provider: "ok", Org: "one"}},
+		Run:       RunConfig{RepoTimeout: time.Minute},
+	}
+	dp := &deadlineProcessor{}
+
+	if _, err := Run(context.Background(), cfg, testFactory(), dp); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(dp.deadlines) != 2 {
+		t.Fatalf("expected 2 Process calls, got %d", len(dp.deadlines))
+	}
+	for _, ok := range dp.deadlines {
+		if !ok {
+			t.Fatalf("expected per-repository context to carry a deadline")
+		}
+	}
+}
+
+// failingProcessor fails every repository at the clone stage.
+type failingProcessor struct{}
+
+func (failingProcessor) Process(_ context.Context, _ GitRepository, _ ProviderConfig, _ Config) (RepoResult, error) {
+	return RepoResult{}, stageError(StageClone, errors.New("boom"))
+}
+
+func TestRun_ReportsResultsAndFailures(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{
+			{Provider: "errlist", Org: "bad"},
+			{Provider: "ok", Org: "good"},
+		},
+	}
+
+	report, err := Run(context.Background(), cfg, testFactory(), failingProcessor{})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(report.ProviderFailures) != 1 || report.ProviderFailures[0].Stage != StageDiscovery {
+		t.Fatalf("expected one discovery failure, got %+v", report.ProviderFailures)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(report.Results))
+	}
+	for _, res := range report.Results {
+		if res.Outcome != OutcomeFailed || res.Stage != StageClone || res.Provider != "ok" || res.Repo.Url == "" {
+			t.Fatalf("unexpected result: %+v", res)
+		}
+	}
+	if report.Status() != RunFailed {
+		t.Fatalf("expected total failure status, got %d", report.Status())
+	}
+}
+
+func TestRun_FiltersTargets(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{
+			{Provider: "ok", Org: "one"},
+			{Provider: "other", Org: "two"},
+		},
+		Run: RunConfig{Targets: TargetFilter{Providers: []string{"ok"}, Repos: []string{"one-2"}}},
+	}
+	rp := &recordingProcessor{}
+
+	if _, err := Run(context.Background(), cfg, testFactory(), rp); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(rp.calls) != 1 || rp.calls[0].repo.Name != "one-2" {
+		t.Fatalf("expected only one-2 to be processed, got %+v", rp.calls)
+	}
+}
+
+func TestRun_InvalidTargetPattern(t *testing.T) {
+	cfg := Config{Run: RunConfig{Targets: TargetFilter{Match: []string{"/(/"}}}}
+	if _, err := Run(context.Background(), cfg, testFactory(), &recordingProcessor{}); err == nil {
+		t.Fatalf("expected error for invalid match pattern")
+	}
+}
+
+// flakyProcessor fails once with a transient error inside Retry before succeeding.
+type flakyProcessor struct{}
+
+func (flakyProcessor) Process(ctx context.Context, _ GitRepository, _ ProviderConfig, _ Config) (RepoResult, error) {
+	calls := 0
+	err := Retry(ctx, "clone", func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return Transient(errors.New("connection reset"))
+		}
+		return nil
+	})
+	return RepoResult{Outcome: OutcomePushed}, err
+}
+
+func TestRun_ReportsRetries(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{{Provider: "ok", Org: "one"}},
+		Retry:     RetryConfig{Attempts: 2, InitialDelay: time.Millisecond},
+	}
+
+	report, err := Run(context.Background(), cfg, testFactory(), flakyProcessor{})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	for _, res := range report.Results {
+		if res.Retries != 1 || res.Outcome != OutcomePushed {
+			t.Fatalf("expected one retry and a push, got %+v", res)
+		}
+	}
+	if report.Retries() != 2 {
+		t.Fatalf("expected 2 retries in total, got %d", report.Retries())
+	}
+}
+
+func TestRun_DiscoversEveryNamespaceOnce(t *testing.T) {
+	var created []ProviderConfig
+	factory := func(pc ProviderConfig) (GitRepositoryProvider, error) {
+		created = append(created, pc)
+		// Every namespace can also reach the shared repository.
+		repos := []GitRepository{
+			{Name: pc.Org, Url: "https://example.com/" + pc.Org + "/repo.git"},
+			{Name: "shared", Url: "https://example.com/Team/Shared.git"},
+		}
+		if pc.Org == "b" {
+			repos[1].Url = "https://example.com/team/shared"
+		}
+		return &fakeProvider{repos: &repos}, nil
+	}
+	proc := &recordingProcessor{}
+	cfg := Config{Providers: []ProviderConfig{{Provider: "ok", Org: "a", Orgs: []string{"b", "A"}, Users: []string{"alice"}}}}
+
+	if _, err := Run(context.Background(), cfg, factory, proc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(created) != 3 {
+		t.Fatalf("expected one provider per namespace, got %+v", created)
+	}
+	if created[2].Org != "alice" || !created[2].UserNamespace() || created[0].UserNamespace() {
+		t.Fatalf("unexpected namespaces: %+v", created)
+	}
+	urls := map[string]string{}
+	for _, c := range proc.calls {
+		urls[c.repo.Url] = c.provider.Org
+	}
+	if len(proc.calls) != 4 || urls["https://example.com/Team/Shared.git"] != "a" {
+		t.Fatalf("expected the shared repository once via the first namespace, got %v", urls)
+	}
+}
+
+// probingProvider serves identifier files by repository name; missing names have no identifier.
+type probingProvider struct {
+	fakeProvider
+	files map[string]string
+}
+
+func (p *probingProvider) ReadIdentifier(_ context.Context, repo GitRepository, path string) ([]byte, error) {
+	if path != ".boneclone.yaml" {
+		return nil, fmt.Errorf("unexpected path %s", path)
+	}
+	if repo.Name == "broken" {
+		return nil, errors.New("api unavailable")
+	}
+	content, ok := p.files[repo.Name]
+	if !ok {
+		return nil, ErrIdentifierNotFound
+	}
+	return []byte(content), nil
+}
+
+func TestRun_ProbesIdentifierBeforeCloning(t *testing.T) {
+	var repos []GitRepository
+	for _, name := range []string{"missing", "other", "accepts", "broken"} {
+		repos = append(repos, GitRepository{Name: name, Url: "https://example.com/o/" + name + ".git"})
+	}
+	provider := &probingProvider{fakeProvider: fakeProvider{repos: &repos}, files: map[string]string{
+		"other":   "accepts: [another-skeleton]\nreviewers: [bob]\n",
+		"accepts": "accepts: [skel]\n",
+	}}
+	factory := func(ProviderConfig) (GitRepositoryProvider, error) { return provider, nil }
+	proc := &recordingProcessor{}
+	cfg := Config{
+		Providers:  []ProviderConfig{{Provider: "ok", Org: "o"}},
+		Identifier: IdentifierConfig{Filename: ".boneclone.yaml", Name: "skel"},
+	}
+
+	report, err := Run(context.Background(), cfg, factory, proc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var processed []string
+	for _, c := range proc.calls {
+		processed = append(processed, c.repo.Name)
+	}
+	sort.Strings(processed)
+	if fmt.Sprint(processed) != "[accepts broken]" {
+		t.Fatalf("expected only accepted and unprobed repositories to be processed, got %v", processed)
+	}
+	outcomes := map[string]Outcome{}
+	for _, r := range report.Results {
+		outcomes[r.Repo.Name] = r.Outcome
+		if r.Repo.Name == "other" && fmt.Sprint(r.Remote.Reviewers) != "[bob]" {
+			t.Fatalf("expected the probed remote config in the result, got %+v", r.Remote)
+		}
+	}
+	if outcomes["missing"] != OutcomeNoIdentifier || outcomes["other"] != OutcomeNotAccepted {
+		t.Fatalf("unexpected outcomes: %v", outcomes)
+	}
+}
+
+func TestRun_ProbeIdentifierTurnedOff(t *testing.T) {
+	repos := []GitRepository{
+		{Name: "missing", Url: "https://example.com/o/missing.git"},
+		{Name: "other", Url: "https://example.com/o/other.git"},
+	}
+	provider := &probingProvider{fakeProvider: fakeProvider{repos: &repos}, files: map[string]string{"other": "accepts: [another-skeleton]\n"}}
+	factory := func(ProviderConfig) (GitRepositoryProvider, error) { return provider, nil }
+	proc := &recordingProcessor{}
+	off := false
+	cfg := Config{
+		Providers:  []ProviderConfig{{Provider: "ok", Org: "o", ProbeIdentifier: &off}},
+		Identifier: IdentifierConfig{Filename: ".boneclone.yaml", Name: "skel"},
+	}
+
+	if _, err := Run(context.Background(), cfg, factory, proc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(proc.calls) != 2 {
+		t.Fatalf("expected every repository to be cloned without probing, got %d", len(proc.calls))
+	}
+}
+
+// searchingProvider finds one repository through search and two by listing.
+type searchingProvider struct {
+	searchErr error
+	searched  []string
+}
+
+func (p *searchingProvider) GetRepositories(context.Context) (*[]GitRepository, error) {
+	return &[]GitRepository{{Name: "a", Url: "https://example.com/o/a.git"}, {Name: "b", Url: "https://example.com/o/b.git"}}, nil
+}
+
+func (p *searchingProvider) SearchRepositories(_ context.Context, path string) (*[]GitRepository, error) {
+	p.searched = append(p.searched, path)
+	if p.searchErr != nil {
+		return nil, p.searchErr
+	}
+	return &[]GitRepository{{Name: "b", Url: "https://example.com/o/b.git"}}, nil
+}
+
+func TestRun_SearchDiscovery(t *testing.T) {
+	cases := []struct {
+		name      string
+		discovery string
+		searchErr error
+		want      int
+	}{
+		{name: "list", want: 2},
+		{name: "search", discovery: DiscoverySearch, want: 1},
+		{name: "search unavailable", discovery: DiscoverySearch, searchErr: errors.New("advanced search disabled"), want: 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := &searchingProvider{searchErr: tc.searchErr}
+			factory := func(ProviderConfig) (GitRepositoryProvider, error) { return provider, nil }
+			proc := &recordingProcessor{}
+			cfg := Config{
+				Providers:  []ProviderConfig{{Provider: "ok", Org: "o", Discovery: tc.discovery}},
+				Identifier: IdentifierConfig{Filename: ".boneclone.yaml"},
+			}
+
+			if _, err := Run(context.Background(), cfg, factory, proc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(proc.calls) != tc.want {
+				t.Fatalf("expected %d repositories, got %d", tc.want, len(proc.calls))
+			}
+			if tc.discovery == DiscoverySearch && fmt.Sprint(provider.searched) != "[.boneclone.yaml]" {
+				t.Fatalf("expected a search for the identifier file, got %v", provider.searched)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// TargetFilter narrows a run to a subset of providers and repositories.
+// Repos are exact repository names; Match entries are globs, or regular expressions when wrapped in slashes
+// (e.g. /^svc-.*$/). Both are compared to the repository name, its last segment when the name is a path
+// (e.g. group/subgroup/my-repo) and its path (e.g. my-org/my-repo).
+// Providers are provider names (e.g. github). Empty lists do not filter.
+type TargetFilter struct {
+	Repos     []string `koanf:"repos"`
+	Match     []string `koanf:"match"`
+	Providers []string `koanf:"providers"`
+}
+
+// targetMatcher is the compiled form of a TargetFilter.
+type targetMatcher struct {
+	repos     map[string]struct{}
+	globs     []string
+	regexps   []*regexp.Regexp
+	providers map[string]struct{}
+}
+
+func newTargetMatcher(f TargetFilter) (*targetMatcher, error) {
+	m := &targetMatcher{repos: map[string]struct{}{}, providers: map[string]struct{}{}}
+	for _, r := range f.Repos {
+		m.repos[strings.TrimSpace(r)] = struct{}{}
+	}
+	for _, p := range f.Providers {
+		m.providers[strings.ToLower(strings.TrimSpace(p))] = struct{}{}
+	}
+	for _, pattern := range f.Match {
+		if expr, ok := regexPattern(pattern); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid match pattern %q: %w", pattern, err)
+			}
+			m.regexps = append(m.regexps, re)
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid match pattern %q: %w", pattern, err)
+		}
+		m.globs = append(m.globs, pattern)
+	}
+	return m, nil
+}
+
+// regexPattern returns the expression inside /.../ when pattern is a regular expression.
+func regexPattern(pattern string) (string, bool) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return pattern[1 : len(pattern)-1], true
+	}
+	return "", false
+}
+
+// provider reports whether repositories from the given provider entry should be discovered at all.
+func (m *targetMatcher) provider(pp ProviderConfig) bool {
+	if len(m.providers) == 0 {
+		return true
+	}
+	_, ok := m.providers[strings.ToLower(pp.Provider)]
+	return ok
+}
+
+// repo reports whether the repository is selected by the --repo and --match filters.
+func (m *targetMatcher) repo(repo GitRepository) bool {
+	if len(m.repos) == 0 && len(m.globs) == 0 && len(m.regexps) == 0 {
+		return true
+	}
+
+	for _, c := range matchCandidates(repo) {
+		if _, ok := m.repos[c]; ok {
+			return true
+		}
+		for _, g := range m.globs {
+			if ok, _ := path.Match(g, c); ok {
+				return true
+			}
+		}
+		for _, re := range m.regexps {
+			if re.MatchString(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// repoPath returns the clone URL path without leading slash or .git suffix, e.g. my-org/my-repo.
+func repoPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+}
+
+// matchCandidates returns the strings repository names and patterns are compared to: the repository name, its
+// last segment when the name is a path (GitLab, Azure DevOps and Bitbucket Server names), and its path from the
+// clone URL.
+func matchCandidates(repo GitRepository) []string {
+	candidates := []string{repo.Name}
+	if short := path.Base(repo.Name); short != repo.Name && short != "." && short != "/" {
+		candidates = append(candidates, short)
+	}
+	if p := repoPath(repo.Url); p != "" && p != repo.Name {
+		candidates = append(candidates, p)
+	}
+	return candidates
+}
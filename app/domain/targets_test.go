@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestTargetMatcher_Repo(t *testing.T) {
+	repo := GitRepository{Name: "svc-billing", Url: "https://github.com/acme/svc-billing.git"}
+	cases := []struct {
+		name   string
+		filter TargetFilter
+		want   bool
+	}{
+		{name: "empty filter", filter: TargetFilter{}, want: true},
+		{name: "exact name", filter: TargetFilter{Repos: []string{"svc-billing"}}, want: true},
+		{name: "exact path", filter: TargetFilter{Repos: []string{"acme/svc-billing"}}, want: true},
+		{name: "other name", filter: TargetFilter{Repos: []string{"svc"}}, want: false},
+		{name: "glob", filter: TargetFilter{Match: []string{"svc-*"}}, want: true},
+		{name: "glob on path", filter: TargetFilter{Match: []string{"acme/*"}}, want: true},
+		{name: "glob no match", filter: TargetFilter{Match: []string{"web-*"}}, want: false},
+		{name: "regex", filter: TargetFilter{Match: []string{"/^svc-(billing|auth)$/"}}, want: true},
+		{name: "regex no match", filter: TargetFilter{Match: []string{"/^web/"}}, want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newTargetMatcher(tc.filter)
+			if err != nil {
+				t.Fatalf("newTargetMatcher: %v", err)
+			}
+			if got := m.repo(repo); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTargetMatcher_RepoNamedByPath(t *testing.T) {
+	repo := GitRepository{Name: "group/sub/svc-billing", Url: "https://gitlab.com/group/sub/svc-billing.git"}
+	for _, filter := range []TargetFilter{
+		{Repos: []string{"svc-billing"}},
+		{Repos: []string{"group/sub/svc-billing"}},
+		{Match: []string{"svc-*"}},
+	} {
+		m, err := newTargetMatcher(filter)
+		if err != nil {
+			t.Fatalf("newTargetMatcher: %v", err)
+		}
+		if !m.repo(repo) {
+			t.Fatalf("expected %+v to select %s", filter, repo.Name)
+		}
+	}
+}
+
+func TestTargetMatcher_Provider(t *testing.T) {
+	m, err := newTargetMatcher(TargetFilter{Providers: []string{"GitLab"}})
+	if err != nil {
+		t.Fatalf("newTargetMatcher: %v", err)
+	}
+	if !m.provider(ProviderConfig{Provider: "gitlab"}) {
+		t.Fatalf("expected gitlab to be selected")
+	}
+	if m.provider(ProviderConfig{Provider: "github"}) {
+		t.Fatalf("expected github not to be selected")
+	}
+}
+
+func TestTargetMatcher_InvalidPatterns(t *testing.T) {
+	for _, pattern := range []string{"/([a-z/", "[a-"} {
+		if _, err := newTargetMatcher(TargetFilter{Match: []string{pattern}}); err == nil {
+			t.Fatalf("expected error for pattern %q", pattern)
+		}
+	}
+}
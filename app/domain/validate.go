@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigProblem describes a single invalid config value, identified by its field path (e.g. providers[0].token).
+type ConfigProblem struct {
+	Field   string
+	Message string
+}
+
+func (p ConfigProblem) String() string { return fmt.Sprintf("%s: %s", p.Field, p.Message) }
+
+// Validate checks the provider-independent parts of the config and returns every problem found.
+// Provider-specific checks live with the providers.
+func (c Config) Validate() []ConfigProblem {
+	var problems []ConfigProblem
+	problems = append(problems, validateProviders(c.Providers)...)
+	problems = append(problems, validateFiles(c.Files)...)
+	problems = append(problems, validateIdentifier(c.Identifier)...)
+	problems = append(problems, validateRun(c.Run)...)
+	problems = append(problems, validateRetry(c.Retry)...)
+	return problems
+}
+
+// problemf returns a ConfigProblem for field with a formatted message.
+func problemf(field, format string, args ...any) ConfigProblem {
+	return ConfigProblem{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+func validateProviders(providers []ProviderConfig) []ConfigProblem {
+	var problems []ConfigProblem
+	if len(providers) == 0 {
+		problems = append(problems, problemf("providers", "no providers configured"))
+	}
+	for i, pp := range providers {
+		if pp.Concurrency < 0 {
+			problems = append(problems, problemf(fmt.Sprintf("providers[%d].concurrency", i), "must not be negative, got %d", pp.Concurrency))
+		}
+	}
+	return problems
+}
+
+func validateFiles(files FileConfig) []ConfigProblem {
+	var problems []ConfigProblem
+	if len(files.Include) == 0 {
+		problems = append(problems, problemf("files.include", "no files or directories to copy"))
+	}
+	for i, inc := range files.Include {
+		if strings.TrimSpace(inc) == "" {
+			problems = append(problems, problemf(fmt.Sprintf("files.include[%d]", i), "is empty"))
+		}
+	}
+	return problems
+}
+
+func validateIdentifier(identifier IdentifierConfig) []ConfigProblem {
+	var problems []ConfigProblem
+	if strings.TrimSpace(identifier.Filename) == "" {
+		problems = append(problems, problemf("identifier.filename", "is required"))
+	}
+	if strings.TrimSpace(identifier.Name) == "" {
+		problems = append(problems, problemf("identifier.name", "is required"))
+	}
+	return problems
+}
+
+// validateRun checks the run's concurrency, host limits, timeouts and targets.
+func validateRun(run RunConfig) []ConfigProblem {
+	var problems []ConfigProblem
+	if run.Concurrency < 0 {
+		problems = append(problems, problemf("run.concurrency", "must not be negative, got %d", run.Concurrency))
+	}
+	if run.Timeout < 0 {
+		problems = append(problems, problemf("run.timeout", "must not be negative, got %s", run.Timeout))
+	}
+	if run.RepoTimeout < 0 {
+		problems = append(problems, problemf("run.repoTimeout", "must not be negative, got %s", run.RepoTimeout))
+	}
+	for i, h := range run.Hosts {
+		if strings.TrimSpace(h.Host) == "" {
+			problems = append(problems, problemf(fmt.Sprintf("run.hosts[%d].host", i), "is required"))
+		}
+		if h.Concurrency <= 0 {
+			problems = append(problems, problemf(fmt.Sprintf("run.hosts[%d].concurrency", i), "must be greater than zero, got %d", h.Concurrency))
+		}
+	}
+	if _, err := newTargetMatcher(run.Targets); err != nil {
+		problems = append(problems, problemf("run.targets.match", "%v", err))
+	}
+	return problems
+}
+
+func validateRetry(retry RetryConfig) []ConfigProblem {
+	var problems []ConfigProblem
+	if retry.Attempts < 0 {
+		problems = append(problems, problemf("retry.attempts", "must not be negative, got %d", retry.Attempts))
+	}
+	if retry.InitialDelay < 0 {
+		problems = append(problems, problemf("retry.initialDelay", "must not be negative, got %s", retry.InitialDelay))
+	}
+	if retry.MaxDelay < 0 {
+		problems = append(problems, problemf("retry.maxDelay", "must not be negative, got %s", retry.MaxDelay))
+	}
+	return problems
+}
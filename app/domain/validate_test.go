@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestConfig_Validate_ReportsAllProblems(t *testing.T) {
+	cfg := Config{
+		Providers: []ProviderConfig{{Provider: "github", Concurrency: -1}},
+		Files:     FileConfig{Include: []string{"ci", " "}},
+		Run:       RunConfig{Concurrency: -2, Hosts: []HostLimit{{Host: "", Concurrency: 0}}},
+	}
+
+	got := map[string]bool{}
+	for _, p := range cfg.Validate() {
+		got[p.Field] = true
+	}
+	for _, field := range []string{
+		"providers[0].concurrency",
+		"files.include[1]",
+		"identifier.filename",
+		"identifier.name",
+		"run.concurrency",
+		"run.hosts[0].host",
+		"run.hosts[0].concurrency",
+	} {
+		if !got[field] {
+			t.Fatalf("expected problem for %s, got %v", field, got)
+		}
+	}
+}
+
+func TestConfig_Validate_ValidConfig(t *testing.T) {
+	cfg := Config{
+		Providers:  []ProviderConfig{{Provider: "github", Org: "o", Token: "t"}},
+		Files:      FileConfig{Include: []string{"ci"}},
+		Identifier: IdentifierConfig{Filename: ".boneclone", Name: "Skeleton"},
+	}
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+}
@@ -0,0 +1,46 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/go-git/go-git/v6/plumbing/transport"
+	"github.com/go-git/go-git/v6/plumbing/transport/http"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// classifyGitError marks clone and push errors that are worth retrying (dropped connections,
+// timeouts, 5xx and 429 responses) as transient. Authentication, missing repositories,
+// cancellation and other errors are returned unchanged as permanent.
+func classifyGitError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(err, transport.ErrAuthenticationRequired) ||
+		errors.Is(err, transport.ErrAuthorizationFailed) ||
+		errors.Is(err, transport.ErrRepositoryNotFound) {
+		return err
+	}
+
+	var httpErr *http.Err
+	if errors.As(err, &httpErr) {
+		if domain.IsTransientStatus(httpErr.StatusCode()) {
+			return domain.Transient(err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return domain.Transient(err)
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/go-git/go-git/v6/plumbing/transport"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+func TestClassifyGitError(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{name: "connection reset", err: fmt.Errorf("push: %w", syscall.ECONNRESET), transient: true},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, transient: true},
+		{name: "authentication", err: transport.ErrAuthenticationRequired, transient: false},
+		{name: "not found", err: transport.ErrRepositoryNotFound, transient: false},
+		{name: "deadline", err: context.DeadlineExceeded, transient: false},
+		{name: "plain error", err: errors.New("boom"), transient: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := domain.IsTransient(classifyGitError(tc.err)); got != tc.transient {
+				t.Fatalf("expected transient=%v, got %v", tc.transient, got)
+			}
+		})
+	}
+}
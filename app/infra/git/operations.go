@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,17 +9,15 @@ import (
 	"strings"
 	"time"
 
-	"github.com/go-git/go-billy/v5"
-	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v6"
+	"github.com/go-git/go-billy/v6/memfs"
 	git "github.com/go-git/go-git/v6"
 	gogitcfg "github.com/go-git/go-git/v6/config"
 	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/client"
 	"github.com/go-git/go-git/v6/plumbing/object"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
 	"github.com/go-git/go-git/v6/storage/memory"
-	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/rawbytes"
-	"github.com/knadh/koanf/v2"
 
 	"go.iain.rocks/boneclone/app/domain"
 )
@@ -27,6 +26,7 @@ const (
 	DefaultCommitterName  = "boneclone"
 	DefaultCommitterEmail = "boneclone@example.org"
 	GitDepth              = 1
+	CommitMessage         = "Updated via boneclone"
 )
 
 // GitOperations defines the operations BoneClone needs for git interactions.
@@ -45,22 +45,44 @@ type Operations struct{}
 // NewOperations creates a new default Operations implementation, returned as a domain.GitOperations.
 func NewOperations() domain.GitOperations { return &Operations{} }
 
+// CredentialResolver returns the HTTP basic auth credentials used to clone from and push to a provider's repositories.
+// It is called before every clone and push so short-lived tokens can be refreshed during long runs.
+type CredentialResolver func(ctx context.Context, config domain.ProviderConfig) (username, password string, err error)
+
+// credentials resolves clone and push credentials; by default it uses the provider's username and token.
+var credentials CredentialResolver = func(_ context.Context, config domain.ProviderConfig) (string, string, error) {
+	return config.Username, config.Token, nil
+}
+
+// SetCredentialResolver replaces how clone and push credentials are resolved, e.g. to use GitHub App installation tokens.
+func SetCredentialResolver(resolver CredentialResolver) {
+	if resolver != nil {
+		credentials = resolver
+	}
+}
+
 // DefaultOps is the package-level default used by the wrapper functions to
 // maintain backward compatibility with existing callers.
 var DefaultOps domain.GitOperations = NewOperations()
 
 // Method implementations
-func (o *Operations) CloneGit(repo domain.GitRepository, config domain.ProviderConfig) (*git.Repository, billy.Filesystem, error) {
-	fs := memfs.New()
-	auth := &http.BasicAuth{
-		Username: config.Username,
-		Password: config.Token,
-	}
-	r, err := git.Clone(memory.NewStorage(), fs, &git.CloneOptions{
-		URL:   repo.Url,
-		Depth: GitDepth,
-		Auth:  auth,
-		Bare:  false,
+// CloneGit makes a shallow in-memory clone, retrying transient failures with a fresh filesystem each time.
+func (o *Operations) CloneGit(ctx context.Context, repo domain.GitRepository, config domain.ProviderConfig) (*git.Repository, billy.Filesystem, error) {
+	var r *git.Repository
+	var fs billy.Filesystem
+	err := domain.Retry(ctx, "clone "+repo.Url, func(ctx context.Context) error {
+		clientOpts, err := clientOptions(ctx, config)
+		if err != nil {
+			return err
+		}
+		fs = memfs.New()
+		r, err = git.CloneContext(ctx, memory.NewStorage(), fs, &git.CloneOptions{
+			URL:           repo.Url,
+			Depth:         GitDepth,
+			ClientOptions: clientOpts,
+			Bare:          false,
+		})
+		return classifyGitError(err)
 	})
 	if err != nil {
 		return nil, nil, err
@@ -69,7 +91,7 @@ func (o *Operations) CloneGit(repo domain.GitRepository, config domain.ProviderC
 	return r, fs, nil
 }
 
-func (o *Operations) IsValidForBoneClone(repo *git.Repository, config domain.Config) (bool, domain.RemoteConfig, error) {
+func (o *Operations) IsValidForBoneClone(_ context.Context, repo *git.Repository, config domain.Config) (bool, domain.RemoteConfig, error) {
 	rCfg := domain.RemoteConfig{}
 
 	headRef, err := repo.Head()
@@ -88,7 +110,7 @@ func (o *Operations) IsValidForBoneClone(repo *git.Repository, config domain.Con
 	file, err := tree.File(config.Identifier.Filename)
 	if err != nil {
 		if errors.Is(err, object.ErrFileNotFound) {
-			return false, rCfg, nil
+			return false, rCfg, domain.ErrIdentifierNotFound
 		}
 		return false, rCfg, err
 	}
@@ -109,89 +131,172 @@ func (o *Operations) IsValidForBoneClone(repo *git.Repository, config domain.Con
 		return false, rCfg, err
 	}
 
-	k := koanf.NewWithConf(koanf.Conf{Delim: ".", StrictMerge: true})
-	if err := k.Load(rawbytes.Provider(content), yaml.Parser()); err != nil {
-		// Invalid YAML -> skip repository without error
-		return false, domain.RemoteConfig{}, nil
-	}
-	if err := k.Unmarshal("", &rCfg); err != nil {
-		// Invalid structure -> skip repository without error
-		return false, domain.RemoteConfig{}, nil
-	}
-
-	skel := strings.TrimSpace(config.Identifier.Name)
-	if skel == "" {
-		return false, rCfg, nil
-	}
-	for _, a := range rCfg.Accepts {
-		if strings.TrimSpace(a) == skel {
-			return true, rCfg, nil
-		}
-	}
-	return false, rCfg, nil
+	// Invalid YAML or structure -> skip repository without error
+	valid, rCfg := domain.EvaluateIdentifier(content, config)
+	return valid, rCfg, nil
 }
 
 func (o *Operations) CopyFiles(
+	ctx context.Context,
 	repo *git.Repository,
 	fs billy.Filesystem,
 	config domain.Config,
 	provider domain.ProviderConfig,
 	targetBranch string,
-) error {
+) (bool, error) {
 	worktree, err := repo.Worktree()
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// Ensure we are operating on the desired target branch (if provided)
 	if err := ensureOnTargetBranch(repo, worktree, targetBranch); err != nil {
-		return err
+		return false, err
 	}
 
-	for _, definedFile := range config.Files.Include {
-		files, err := getAllFilenames(definedFile)
-		if err != nil {
-			return err
-		}
+	if _, err := stageFiles(fs, worktree, config); err != nil {
+		return false, err
+	}
 
-		for _, file := range files {
-			if isExcluded(file, config.Files.Exclude) {
-				continue
-			}
-			if err := writeAndStageFile(fs, worktree, file); err != nil {
-				return err
-			}
-		}
+	return commitAndPush(ctx, repo, worktree, config, provider, targetBranch)
+}
 
-		upToDate, err := commitAndPush(repo, worktree, config, provider, targetBranch)
-		if err != nil {
-			return err
-		}
-		if upToDate {
-			return nil
+func (o *Operations) PlanFiles(
+	_ context.Context,
+	repo *git.Repository,
+	fs billy.Filesystem,
+	config domain.Config,
+	targetBranch string,
+) ([]domain.FileChange, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureOnTargetBranch(repo, worktree, targetBranch); err != nil {
+		return nil, err
+	}
+
+	files, err := stageFiles(fs, worktree, config)
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]domain.FileChange, 0, len(files))
+	for _, file := range files {
+		changes = append(changes, domain.FileChange{Path: file, Status: changeStatus(status, file)})
+	}
+	return changes, nil
+}
+
+// DiffFiles stages the configured files, commits them to the in-memory clone only and returns
+// the unified diff between the previous HEAD and that commit. Nothing is pushed.
+// An empty string means the copy produces no changes.
+func (o *Operations) DiffFiles(
+	ctx context.Context,
+	repo *git.Repository,
+	fs billy.Filesystem,
+	config domain.Config,
+	targetBranch string,
+) (string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	if err := ensureOnTargetBranch(repo, worktree, targetBranch); err != nil {
+		return "", err
+	}
+	if _, err := stageFiles(fs, worktree, config); err != nil {
+		return "", err
+	}
+
+	headRef, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	parent, err := repo.CommitObject(headRef.Hash())
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := worktree.Commit(CommitMessage, &git.CommitOptions{Author: commitSignature(config)})
+	if err != nil {
+		if errors.Is(err, git.ErrEmptyCommit) {
+			return "", nil
 		}
+		return "", err
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	patch, err := parent.PatchContext(ctx, commit)
+	if err != nil {
+		return "", err
+	}
+	return patch.String(), nil
 }
 
 // Wrapper functions for backward compatibility with existing callers.
-func CloneGit(repo domain.GitRepository, config domain.ProviderConfig) (*git.Repository, billy.Filesystem, error) {
-	return DefaultOps.CloneGit(repo, config)
+func CloneGit(ctx context.Context, repo domain.GitRepository, config domain.ProviderConfig) (*git.Repository, billy.Filesystem, error) {
+	return DefaultOps.CloneGit(ctx, repo, config)
 }
 
-func IsValidForBoneClone(repo *git.Repository, config domain.Config) (bool, domain.RemoteConfig, error) {
-	return DefaultOps.IsValidForBoneClone(repo, config)
+func IsValidForBoneClone(ctx context.Context, repo *git.Repository, config domain.Config) (bool, domain.RemoteConfig, error) {
+	return DefaultOps.IsValidForBoneClone(ctx, repo, config)
 }
 
 func CopyFiles(
+	ctx context.Context,
 	repo *git.Repository,
 	fs billy.Filesystem,
 	config domain.Config,
 	provider domain.ProviderConfig,
 	targetBranch string,
-) error {
-	return DefaultOps.CopyFiles(repo, fs, config, provider, targetBranch)
+) (bool, error) {
+	return DefaultOps.CopyFiles(ctx, repo, fs, config, provider, targetBranch)
+}
+
+// stageFiles writes every included, non-excluded file into fs and stages it, returning the staged paths.
+func stageFiles(fs billy.Filesystem, worktree *git.Worktree, config domain.Config) ([]string, error) {
+	var staged []string
+	for _, definedFile := range config.Files.Include {
+		files, err := getAllFilenames(definedFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if isExcluded(file, config.Files.Exclude) {
+				continue
+			}
+			if err := writeAndStageFile(fs, worktree, file); err != nil {
+				return nil, err
+			}
+			staged = append(staged, file)
+		}
+	}
+	return staged, nil
+}
+
+// changeStatus maps the staging status of a file to the domain change status.
+func changeStatus(status git.Status, file string) domain.ChangeStatus {
+	fileStatus, ok := status[file]
+	if !ok {
+		return domain.ChangeUnchanged
+	}
+	switch fileStatus.Staging {
+	case git.Added:
+		return domain.ChangeAdded
+	case git.Modified:
+		return domain.ChangeModified
+	default:
+		return domain.ChangeUnchanged
+	}
 }
 
 func writeAndStageFile(fs billy.Filesystem, worktree *git.Worktree, file string) error {
@@ -275,35 +380,31 @@ func ensureOnTargetBranch(repo *git.Repository, worktree *git.Worktree, targetBr
 }
 
 // commitAndPush creates a commit with configured author defaults and pushes it.
-// It returns alreadyUpToDate=true when the push indicates no changes.
-func commitAndPush(repo *git.Repository, worktree *git.Worktree, config domain.Config, provider domain.ProviderConfig, targetBranch string) (bool, error) {
-	name := config.Git.Name
-	if name == "" {
-		name = DefaultCommitterName
-	}
-	email := config.Git.Email
-	if email == "" {
-		email = DefaultCommitterEmail
-	}
-
-	if _, err := worktree.Commit("Updated via boneclone", &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  name,
-			Email: email,
-			When:  time.Now(),
-		},
+// It returns alreadyUpToDate=true when there is nothing to commit or the push indicates no changes.
+func commitAndPush(ctx context.Context, repo *git.Repository, worktree *git.Worktree, config domain.Config, provider domain.ProviderConfig, targetBranch string) (bool, error) {
+	if _, err := worktree.Commit(CommitMessage, &git.CommitOptions{
+		Author: commitSignature(config),
 	}); err != nil {
+		if errors.Is(err, git.ErrEmptyCommit) {
+			return true, nil
+		}
 		return false, err
 	}
 
-	opts := &git.PushOptions{
-		Auth: &http.BasicAuth{Username: provider.Username, Password: provider.Token},
-	}
+	opts := &git.PushOptions{}
 	if tb := strings.TrimSpace(targetBranch); tb != "" {
 		localRef := "refs/heads/" + tb
 		opts.RefSpecs = []gogitcfg.RefSpec{gogitcfg.RefSpec(localRef + ":" + localRef)}
 	}
-	if err := repo.Push(opts); err != nil {
+	err := domain.Retry(ctx, "push "+targetBranch, func(ctx context.Context) error {
+		clientOpts, err := clientOptions(ctx, provider)
+		if err != nil {
+			return err
+		}
+		opts.ClientOptions = clientOpts
+		return classifyGitError(repo.PushContext(ctx, opts))
+	})
+	if err != nil {
 		if errors.Is(err, git.NoErrAlreadyUpToDate) {
 			return true, nil
 		}
@@ -312,6 +413,32 @@ func commitAndPush(repo *git.Repository, worktree *git.Worktree, config domain.C
 	return false, nil
 }
 
+// clientOptions resolves the current credentials for provider into git client options.
+func clientOptions(ctx context.Context, provider domain.ProviderConfig) ([]client.Option, error) {
+	username, password, err := credentials(ctx, provider)
+	if err != nil {
+		return nil, fmt.Errorf("resolving git credentials: %w", err)
+	}
+	return []client.Option{client.WithHTTPAuth(&http.BasicAuth{Username: username, Password: password})}, nil
+}
+
+// commitSignature returns the commit author from config, falling back to the BoneClone defaults.
+func commitSignature(config domain.Config) *object.Signature {
+	name := config.Git.Name
+	if name == "" {
+		name = DefaultCommitterName
+	}
+	email := config.Git.Email
+	if email == "" {
+		email = DefaultCommitterEmail
+	}
+	return &object.Signature{
+		Name:  name,
+		Email: email,
+		When:  time.Now(),
+	}
+}
+
 func getAllFilenames(filename string) ([]string, error) {
 	output := []string{}
 
@@ -0,0 +1,165 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/transport"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// newBareRepo creates a bare repository on disk containing a single commit of files on branch main.
+func newBareRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	src := t.TempDir()
+	repo, err := git.PlainInit(src, false, git.WithDefaultBranch(plumbing.NewBranchReferenceName("main")))
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("worktree: %v", err)
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(src, name)), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+	}
+	if _, err := wt.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "t", Email: "t@example.org", When: time.Now()}}); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	bare := filepath.Join(t.TempDir(), "repo.git")
+	if _, err := git.PlainClone(bare, &git.CloneOptions{URL: src, Bare: true}); err != nil {
+		t.Fatalf("bare clone: %v", err)
+	}
+	return bare
+}
+
+func TestCopyFiles_PushesToLocalBareRepo(t *testing.T) {
+	bare := newBareRepo(t, map[string]string{".boneclone": "accepts:\n  - Skeleton\n"})
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ci"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ci", "build.sh"), []byte("echo build\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Chdir(dir)
+
+	ops := NewOperations()
+	ctx := context.Background()
+	cfg := domain.Config{Files: domain.FileConfig{Include: []string{"ci"}}}
+	repo, fs, err := ops.CloneGit(ctx, domain.GitRepository{Name: "repo", Url: "file://" + filepath.ToSlash(bare)}, domain.ProviderConfig{})
+	if err != nil {
+		t.Fatalf("CloneGit: %v", err)
+	}
+	upToDate, err := ops.CopyFiles(ctx, repo, fs, cfg, domain.ProviderConfig{}, "main")
+	if err != nil {
+		t.Fatalf("CopyFiles: %v", err)
+	}
+	if upToDate {
+		t.Fatalf("expected a push, got up to date")
+	}
+
+	pushed, err := git.PlainOpen(bare)
+	if err != nil {
+		t.Fatalf("open bare: %v", err)
+	}
+	head, err := pushed.Reference(plumbing.NewBranchReferenceName("main"), true)
+	if err != nil {
+		t.Fatalf("reference: %v", err)
+	}
+	commit, err := pushed.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if _, err := commit.File("ci/build.sh"); err != nil {
+		t.Fatalf("expected ci/build.sh in pushed commit: %v", err)
+	}
+}
+
+func TestCopyFiles_PushesToSeveralLocalBareReposConcurrently(t *testing.T) {
+	const repos = 4
+	bares := make([]string, repos)
+	for i := range bares {
+		bares[i] = newBareRepo(t, map[string]string{".boneclone": "accepts:\n  - Skeleton\n"})
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ci"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ci", "build.sh"), []byte("echo build\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Chdir(dir)
+
+	ops := NewOperations()
+	ctx := context.Background()
+	cfg := domain.Config{Files: domain.FileConfig{Include: []string{"ci"}}}
+	errs := make(chan error, repos)
+	var wg sync.WaitGroup
+	for _, bare := range bares {
+		wg.Add(1)
+		go func(bare string) {
+			defer wg.Done()
+			repo, fs, err := ops.CloneGit(ctx, domain.GitRepository{Name: filepath.Base(bare), Url: "file://" + filepath.ToSlash(bare)}, domain.ProviderConfig{})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if _, err := ops.CopyFiles(ctx, repo, fs, cfg, domain.ProviderConfig{}, "main"); err != nil {
+				errs <- err
+			}
+		}(bare)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent push: %v", err)
+	}
+
+	for _, bare := range bares {
+		pushed, err := git.PlainOpen(bare)
+		if err != nil {
+			t.Fatalf("open bare: %v", err)
+		}
+		head, err := pushed.Reference(plumbing.NewBranchReferenceName("main"), true)
+		if err != nil {
+			t.Fatalf("reference: %v", err)
+		}
+		commit, err := pushed.CommitObject(head.Hash())
+		if err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+		if _, err := commit.File("ci/build.sh"); err != nil {
+			t.Fatalf("expected ci/build.sh in %s: %v", bare, err)
+		}
+	}
+}
+
+func TestCloneGit_MissingLocalRepoFails(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.git")
+	ops := NewOperations()
+	_, _, err := ops.CloneGit(context.Background(), domain.GitRepository{Name: "missing", Url: "file://" + filepath.ToSlash(missing)}, domain.ProviderConfig{})
+	if !errors.Is(err, transport.ErrRepositoryNotFound) {
+		t.Fatalf("expected repository not found, got %v", err)
+	}
+}
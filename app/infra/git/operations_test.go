@@ -1,9 +1,22 @@
 package git
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v6"
+	"github.com/go-git/go-billy/v6/memfs"
+	"github.com/go-git/go-billy/v6/util"
+	git "github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/storage/memory"
+
+	"go.iain.rocks/boneclone/app/domain"
 )
 
 func TestIsExcluded(t *testing.T) {
@@ -61,3 +74,109 @@ func TestGetAllFilenames_DirAndFile(t *testing.T) {
 		t.Fatalf("expected single file %s, got %v", a, files2)
 	}
 }
+
+// newMemoryRepo creates an in-memory repository with a single commit containing the given files.
+func newMemoryRepo(t *testing.T, files map[string]string) (*git.Repository, billy.Filesystem) {
+	t.Helper()
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), git.WithWorkTree(fs))
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("worktree: %v", err)
+	}
+	for name, content := range files {
+		if err := util.WriteFile(fs, name, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+	}
+	if _, err := wt.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "t", Email: "t@example.org", When: time.Now()}}); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	return repo, fs
+}
+
+func TestPlanFiles_ReportsAddedModifiedUnchanged(t *testing.T) {
+	repo, fs := newMemoryRepo(t, map[string]string{"ci/a.sh": "old", "ci/c.sh": "same"})
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ci"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, content := range map[string]string{"ci/a.sh": "new", "ci/b.sh": "b", "ci/c.sh": "same"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	t.Chdir(dir)
+
+	cfg := domain.Config{Files: domain.FileConfig{Include: []string{"ci"}}}
+	changes, err := NewOperations().PlanFiles(context.Background(), repo, fs, cfg, "")
+	if err != nil {
+		t.Fatalf("PlanFiles: %v", err)
+	}
+
+	got := map[string]domain.ChangeStatus{}
+	for _, c := range changes {
+		got[c.Path] = c.Status
+	}
+	want := map[string]domain.ChangeStatus{
+		"ci/a.sh": domain.ChangeModified,
+		"ci/b.sh": domain.ChangeAdded,
+		"ci/c.sh": domain.ChangeUnchanged,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected changes\nGot:  %v\nWant: %v", got, want)
+	}
+}
+
+func TestDiffFiles_RendersUnifiedDiff(t *testing.T) {
+	repo, fs := newMemoryRepo(t, map[string]string{"ci/a.sh": "old\n"})
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ci"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ci/a.sh"), []byte("new\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Chdir(dir)
+
+	cfg := domain.Config{Files: domain.FileConfig{Include: []string{"ci"}}}
+	diff, err := NewOperations().DiffFiles(context.Background(), repo, fs, cfg, "")
+	if err != nil {
+		t.Fatalf("DiffFiles: %v", err)
+	}
+	for _, want := range []string{"diff --git a/ci/a.sh b/ci/a.sh", "-old", "+new"} {
+		if !strings.Contains(diff, want) {
+			t.Fatalf("expected diff to contain %q, got:\n%s", want, diff)
+		}
+	}
+}
+
+func TestDiffFiles_NoChanges(t *testing.T) {
+	repo, fs := newMemoryRepo(t, map[string]string{"ci/a.sh": "same\n"})
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ci"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ci/a.sh"), []byte("same\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Chdir(dir)
+
+	cfg := domain.Config{Files: domain.FileConfig{Include: []string{"ci"}}}
+	diff, err := NewOperations().DiffFiles(context.Background(), repo, fs, cfg, "")
+	if err != nil {
+		t.Fatalf("DiffFiles: %v", err)
+	}
+	if diff != "" {
+		t.Fatalf("expected empty diff, got:\n%s", diff)
+	}
+}
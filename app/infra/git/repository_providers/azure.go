@@ -3,6 +3,9 @@ package repository_providers
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
@@ -24,21 +27,23 @@ type gitClient interface {
 }
 
 // Constructors are variables so tests can stub them.
-var newCoreClient = func(ctx context.Context, conn *azuredevops.Connection) (coreClient, error) {
-	return core.NewClient(ctx, conn)
+// The clients are built against the organization URL directly so their requests go through the rate limited HTTP client.
+var newCoreClient = func(ctx context.Context, conn *azuredevops.Connection, httpClient *http.Client) (coreClient, error) {
+	return &core.ClientImpl{Client: *azuredevops.NewClientWithOptions(conn, conn.BaseUrl, azuredevops.WithHTTPClient(httpClient))}, nil
 }
 
-var newGitClient = func(ctx context.Context, conn *azuredevops.Connection) (gitClient, error) {
-	return git.NewClient(ctx, conn)
+var newGitClient = func(ctx context.Context, conn *azuredevops.Connection, httpClient *http.Client) (gitClient, error) {
+	return &git.ClientImpl{Client: *azuredevops.NewClientWithOptions(conn, conn.BaseUrl, azuredevops.WithHTTPClient(httpClient))}, nil
 }
 
 type AzureRepositoryProvider struct {
 	connection *azuredevops.Connection
-	ctx        context.Context
+	httpClient *http.Client
+	filter     *domain.RepositoryMatcher
 }
 
 func (a AzureRepositoryProvider) CreatePullRequest(ctx context.Context, repo, baseBranch, headBranch, title string, filesChanged []string, originalAuthor string, buildBody domain.PRBodyBuilder) (domain.PRInfo, error) {
-	gc, err := newGitClient(ctx, a.connection)
+	gc, err := newGitClient(ctx, a.connection, a.httpClient)
 	if err != nil {
 		return domain.PRInfo{}, err
 	}
@@ -72,7 +77,9 @@ func (a AzureRepositoryProvider) CreatePullRequest(ctx context.Context, repo, ba
 		RepositoryId:           &repoName,
 	}
 
-	created, err := gc.CreatePullRequest(ctx, args)
+	created, err := callAPI(ctx, "create azure pull request for "+repo, func(ctx context.Context) (*git.GitPullRequest, error) {
+		return gc.CreatePullRequest(ctx, args)
+	})
 	if err != nil {
 		return domain.PRInfo{}, err
 	}
@@ -95,7 +102,7 @@ func (a AzureRepositoryProvider) AssignReviewers(ctx context.Context, repo strin
 		return nil
 	}
 
-	gc, err := newGitClient(ctx, a.connection)
+	gc, err := newGitClient(ctx, a.connection, a.httpClient)
 	if err != nil {
 		return err
 	}
@@ -139,31 +146,34 @@ func (a AzureRepositoryProvider) AssignReviewers(ctx context.Context, repo strin
 		RepositoryId:  &repoName,
 		PullRequestId: &pr.ID,
 	}
-	_, err = rc.CreatePullRequestReviewers(ctx, args)
+	_, err = callAPI(ctx, "add azure reviewers for "+repo, func(ctx context.Context) (*[]webapi.IdentityRef, error) {
+		return rc.CreatePullRequestReviewers(ctx, args)
+	})
 	return err
 }
 
-func (a AzureRepositoryProvider) GetRepositories() (*[]domain.GitRepository, error) {
+// GetRepositories lists the repositories of every project in the organization and drops those skipped
+// by the provider's filter. Azure DevOps does not report repository activity, so activeWithin keeps them all.
+func (a AzureRepositoryProvider) GetRepositories(ctx context.Context) (*[]domain.GitRepository, error) {
 	var output []domain.GitRepository
 
-	coreClient, err := newCoreClient(a.ctx, a.connection)
-	getProjectsArgs := core.GetProjectsArgs{}
+	coreClient, err := newCoreClient(ctx, a.connection, a.httpClient)
 	if err != nil {
 		return nil, err
 	}
 
-	gitClient, err := newGitClient(a.ctx, a.connection)
+	gitClient, err := newGitClient(ctx, a.connection, a.httpClient)
 	if err != nil {
 		return nil, err
 	}
 
-	projectsResponse, err := coreClient.GetProjects(a.ctx, getProjectsArgs)
+	projects, err := listAzureProjects(ctx, coreClient)
 	if err != nil {
 		return nil, err
 	}
 
 	trueValue := true
-	for _, project := range projectsResponse.Value {
+	for _, project := range projects {
 		getReposArgs := git.GetRepositoriesArgs{
 			Project:        project.Name,
 			IncludeHidden:  &trueValue,
@@ -171,27 +181,122 @@ func (a AzureRepositoryProvider) GetRepositories() (*[]domain.GitRepository, err
 			IncludeLinks:   &trueValue,
 		}
 
-		repositories, err := gitClient.GetRepositories(a.ctx, getReposArgs)
+		repositories, err := callAPI(ctx, "list azure repositories for "+*project.Name, func(ctx context.Context) (*[]git.GitRepository, error) {
+			return gitClient.GetRepositories(ctx, getReposArgs)
+		})
 		if err != nil {
 			return nil, err
 		}
 
 		if repositories != nil {
 			for _, repo := range *repositories {
-				output = append(output, domain.GitRepository{
-					Name: *project.Name,
-					Url:  *repo.RemoteUrl,
-				})
+				output = append(output, azureRepository(project, repo))
 			}
 		}
 	}
 
+	output = a.filter.Filter(output)
 	return &output, nil
 }
 
-func NewAzureRepositoryProvider(token, org string) (domain.GitRepositoryProvider, error) {
-	connection := azuredevops.NewPatConnection(org, token)
-	ctx := context.Background()
+// listAzureProjects lists every project in the organization, following continuation tokens until the last page.
+func listAzureProjects(ctx context.Context, client coreClient) ([]core.TeamProjectReference, error) {
+	var projects []core.TeamProjectReference
+	args := core.GetProjectsArgs{}
+	for {
+		page, err := callAPI(ctx, "list azure projects", func(ctx context.Context) (*core.GetProjectsResponseValue, error) {
+			return client.GetProjects(ctx, args)
+		})
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, page.Value...)
+
+		if page.ContinuationToken == "" {
+			return projects, nil
+		}
+		token, err := strconv.Atoi(page.ContinuationToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid azure projects continuation token %q: %w", page.ContinuationToken, err)
+		}
+		args.ContinuationToken = &token
+	}
+}
+
+// ReadIdentifier reads path from the repository's default branch through the items API.
+func (a AzureRepositoryProvider) ReadIdentifier(ctx context.Context, repo domain.GitRepository, path string) ([]byte, error) {
+	gc, err := newGitClient(ctx, a.connection, a.httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Define a narrow interface for the items API to avoid widening our gitClient test seam.
+	type itemContentClient interface {
+		GetItemContent(context.Context, git.GetItemContentArgs) (io.ReadCloser, error)
+	}
+	ic, ok := gc.(itemContentClient)
+	if !ok {
+		return nil, fmt.Errorf("azure git client cannot read items")
+	}
+
+	project, repoName, ok := strings.Cut(repo.Name, "/")
+	if !ok {
+		return nil, fmt.Errorf("azure repo must be 'Project/Repository', got: %s", repo.Name)
+	}
+	repoID := repoName
+	if repo.ID != "" {
+		repoID = repo.ID
+	}
+	args := git.GetItemContentArgs{Project: &project, RepositoryId: &repoID, Path: &path}
+	content, err := callAPI(ctx, "read azure "+path+" for "+repo.Name, func(ctx context.Context) ([]byte, error) {
+		body, err := ic.GetItemContent(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = body.Close() }()
+		return io.ReadAll(body)
+	})
+	if isNotFound(err) {
+		return nil, domain.ErrIdentifierNotFound
+	}
+	return content, err
+}
+
+// azureRepository converts a repository listed in project. Its name is "Project/Repository",
+// the form CreatePullRequest and AssignReviewers expect.
+func azureRepository(project core.TeamProjectReference, repo git.GitRepository) domain.GitRepository {
+	projectName := deref(project.Name)
+	output := domain.GitRepository{
+		Name:          projectName + "/" + deref(repo.Name),
+		Url:           deref(repo.RemoteUrl),
+		Owner:         projectName,
+		DefaultBranch: strings.TrimPrefix(deref(repo.DefaultBranch), "refs/heads/"),
+		Archived:      repo.IsDisabled != nil && *repo.IsDisabled,
+		Fork:          repo.IsFork != nil && *repo.IsFork,
+	}
+	if repo.Id != nil {
+		output.ID = repo.Id.String()
+	}
+	if project.Visibility != nil {
+		output.Visibility = strings.ToLower(string(*project.Visibility))
+	}
+	return output
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// NewAzureRepositoryProvider creates a provider for the Azure DevOps organization at config.Org.
+func NewAzureRepositoryProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+	filter, err := domain.NewRepositoryMatcher(config.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid azure filter: %w", err)
+	}
+	connection := azuredevops.NewPatConnection(config.Org, config.Token)
 
-	return &AzureRepositoryProvider{connection, ctx}, nil
+	return &AzureRepositoryProvider{connection: connection, httpClient: newRateLimitedClient(budgetFor(ProviderAzure, config.Org, config.Token)), filter: filter}, nil
 }
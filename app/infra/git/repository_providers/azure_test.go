@@ -3,6 +3,8 @@ package repository_providers
 import (
 	"context"
 	"errors"
+	"io"
+	"net/http"
 	"reflect"
 	"strings"
 	"testing"
@@ -67,38 +69,38 @@ func TestAzureProvider_GetRepositories_Success(t *testing.T) {
 		Value: []core.TeamProjectReference{p1, p2},
 	}
 
-	r1 := git.GitRepository{RemoteUrl: strPtr("https://dev.azure.com/org/ProjectOne/_git/repo1")}
-	r2 := git.GitRepository{RemoteUrl: strPtr("https://dev.azure.com/org/ProjectOne/_git/repo2")}
-	r3 := git.GitRepository{RemoteUrl: strPtr("https://dev.azure.com/org/ProjectTwo/_git/repoA")}
+	r1 := git.GitRepository{Name: strPtr("repo1"), RemoteUrl: strPtr("https://dev.azure.com/org/ProjectOne/_git/repo1")}
+	r2 := git.GitRepository{Name: strPtr("repo2"), RemoteUrl: strPtr("https://dev.azure.com/org/ProjectOne/_git/repo2")}
+	r3 := git.GitRepository{Name: strPtr("repoA"), RemoteUrl: strPtr("https://dev.azure.com/org/ProjectTwo/_git/repoA")}
 
 	// Inject fakes
-	newCoreClient = func(ctx context.Context, _ *azuredevops.Connection) (coreClient, error) { // connection not used in fake
+	newCoreClient = func(ctx context.Context, _ *azuredevops.Connection, _ *http.Client) (coreClient, error) { // connection not used in fake
 		return fakeCoreClient{projects: projects}, nil
 	}
 	// First call for ProjectOne returns r1,r2; second call returns r3.
 	call := 0
-	newGitClient = func(ctx context.Context, _ *azuredevops.Connection) (gitClient, error) {
+	newGitClient = func(ctx context.Context, _ *azuredevops.Connection, _ *http.Client) (gitClient, error) {
 		call = 0 // ensure fresh counter per provider creation
 		return fakeGitClient{}, nil
 	}
 	// We can't inject behavior via constructor easily, so instead wrap GetRepositories with a closure over call
 	// Redefine newGitClient to return a stateful fake implementing the interface
-	newGitClient = func(ctx context.Context, _ *azuredevops.Connection) (gitClient, error) {
+	newGitClient = func(ctx context.Context, _ *azuredevops.Connection, _ *http.Client) (gitClient, error) {
 		return &statefulGitFake{calls: &call, slices: [][]git.GitRepository{{r1, r2}, {r3}}}, nil
 	}
 
 	// Act
-	provider := &AzureRepositoryProvider{connection: nil, ctx: context.Background()}
-	got, err := provider.GetRepositories()
+	provider := &AzureRepositoryProvider{connection: nil}
+	got, err := provider.GetRepositories(context.Background())
 	if err != nil {
 		t.Fatalf("GetRepositories unexpected error: %v", err)
 	}
 
 	// Assert
 	want := []domain.GitRepository{
-		{Name: "ProjectOne", Url: *r1.RemoteUrl},
-		{Name: "ProjectOne", Url: *r2.RemoteUrl},
-		{Name: "ProjectTwo", Url: *r3.RemoteUrl},
+		{Name: "ProjectOne/repo1", Url: *r1.RemoteUrl, Owner: "ProjectOne"},
+		{Name: "ProjectOne/repo2", Url: *r2.RemoteUrl, Owner: "ProjectOne"},
+		{Name: "ProjectTwo/repoA", Url: *r3.RemoteUrl, Owner: "ProjectTwo"},
 	}
 	if !reflect.DeepEqual(*got, want) {
 		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
@@ -125,28 +127,80 @@ func (s *statefulGitFake) CreatePullRequest(ctx context.Context, args git.Create
 	return &git.GitPullRequest{}, nil
 }
 
+// pagedCoreClient returns one page of projects per call and records the continuation token of each request.
+type pagedCoreClient struct {
+	pages  []core.GetProjectsResponseValue
+	tokens []int
+}
+
+func (p *pagedCoreClient) GetProjects(ctx context.Context, args core.GetProjectsArgs) (*core.GetProjectsResponseValue, error) {
+	token := 0
+	if args.ContinuationToken != nil {
+		token = *args.ContinuationToken
+	}
+	p.tokens = append(p.tokens, token)
+	page := p.pages[len(p.tokens)-1]
+	return &page, nil
+}
+
+func TestAzureProvider_GetRepositories_FollowsProjectContinuationToken(t *testing.T) {
+	origCore := newCoreClient
+	origGit := newGitClient
+	t.Cleanup(func() { newCoreClient = origCore; newGitClient = origGit })
+
+	projects := &pagedCoreClient{pages: []core.GetProjectsResponseValue{
+		{Value: []core.TeamProjectReference{{Name: strPtr("ProjectOne")}}, ContinuationToken: "100"},
+		{Value: []core.TeamProjectReference{{Name: strPtr("ProjectTwo")}}},
+	}}
+	r1 := git.GitRepository{Name: strPtr("repo1"), RemoteUrl: strPtr("https://dev.azure.com/org/ProjectOne/_git/repo1")}
+	r2 := git.GitRepository{Name: strPtr("repo2"), RemoteUrl: strPtr("https://dev.azure.com/org/ProjectTwo/_git/repo2")}
+	call := 0
+	newCoreClient = func(ctx context.Context, _ *azuredevops.Connection, _ *http.Client) (coreClient, error) {
+		return projects, nil
+	}
+	newGitClient = func(ctx context.Context, _ *azuredevops.Connection, _ *http.Client) (gitClient, error) {
+		return &statefulGitFake{calls: &call, slices: [][]git.GitRepository{{r1}, {r2}}}, nil
+	}
+
+	provider := &AzureRepositoryProvider{connection: nil}
+	got, err := provider.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(projects.tokens, []int{0, 100}) {
+		t.Fatalf("expected the second request to pass the continuation token, got %v", projects.tokens)
+	}
+	want := []domain.GitRepository{
+		{Name: "ProjectOne/repo1", Url: *r1.RemoteUrl, Owner: "ProjectOne"},
+		{Name: "ProjectTwo/repo2", Url: *r2.RemoteUrl, Owner: "ProjectTwo"},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
+	}
+}
+
 func TestAzureProvider_GetRepositories_CoreError(t *testing.T) {
 	origCore := newCoreClient
 	origGit := newGitClient
 	// restore
 	t.Cleanup(func() { newCoreClient = origCore; newGitClient = origGit })
 
-	newCoreClient = func(ctx context.Context, _ *azuredevops.Connection) (coreClient, error) {
+	newCoreClient = func(ctx context.Context, _ *azuredevops.Connection, _ *http.Client) (coreClient, error) {
 		return fakeCoreClient{err: errors.New("boom")}, nil
 	}
-	newGitClient = func(ctx context.Context, _ *azuredevops.Connection) (gitClient, error) {
+	newGitClient = func(ctx context.Context, _ *azuredevops.Connection, _ *http.Client) (gitClient, error) {
 		return fakeGitClient{}, nil
 	}
 
-	provider := &AzureRepositoryProvider{connection: nil, ctx: context.Background()}
-	repos, err := provider.GetRepositories()
+	provider := &AzureRepositoryProvider{connection: nil}
+	repos, err := provider.GetRepositories(context.Background())
 	if err == nil {
 		t.Fatalf("expected error, got nil and repos=%v", repos)
 	}
 }
 
 func TestNewAzureRepositoryProvider_Constructs(t *testing.T) {
-	p, err := NewAzureRepositoryProvider("token", "https://dev.azure.com/org")
+	p, err := NewAzureRepositoryProvider(domain.ProviderConfig{Token: "token", Org: "https://dev.azure.com/org"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -179,11 +233,11 @@ func TestAzureProvider_CreatePullRequest_BuildsArgs(t *testing.T) {
 	defer func() { newGitClient = origGit }()
 
 	cap := &capturingGitClient{}
-	newGitClient = func(ctx context.Context, _ *azuredevops.Connection) (gitClient, error) {
+	newGitClient = func(ctx context.Context, _ *azuredevops.Connection, _ *http.Client) (gitClient, error) {
 		return cap, nil
 	}
 
-	provider := &AzureRepositoryProvider{connection: nil, ctx: context.Background()}
+	provider := &AzureRepositoryProvider{connection: nil}
 	files := []string{"dir/a.txt", "b.md"}
 	author := "Alice"
 	_, err := provider.CreatePullRequest(context.Background(), "ProjX/RepoY", "main", "boneclone/update", domain.DefaultPRTitle, files, author, domain.DefaultPRBodyBuilder)
@@ -216,3 +270,61 @@ func TestAzureProvider_CreatePullRequest_BuildsArgs(t *testing.T) {
 		t.Fatalf("unexpected description: %q", desc)
 	}
 }
+
+func TestAzureRepository_Metadata(t *testing.T) {
+	visibility := core.ProjectVisibilityValues.Private
+	yes := true
+	got := azureRepository(
+		core.TeamProjectReference{Name: strPtr("Platform"), Visibility: &visibility},
+		git.GitRepository{Name: strPtr("api"), RemoteUrl: strPtr("https://dev.azure.com/org/Platform/_git/api"), DefaultBranch: strPtr("refs/heads/main"), IsFork: &yes},
+	)
+	want := domain.GitRepository{
+		Name:          "Platform/api",
+		Url:           "https://dev.azure.com/org/Platform/_git/api",
+		Owner:         "Platform",
+		DefaultBranch: "main",
+		Fork:          true,
+		Visibility:    domain.VisibilityPrivate,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("azureRepository mismatch\nGot:  %#v\nWant: %#v", got, want)
+	}
+}
+
+// itemGitFake serves identifier files through GetItemContent; other paths are 404s.
+type itemGitFake struct {
+	fakeGitClient
+	files map[string]string
+	args  []git.GetItemContentArgs
+}
+
+func (f *itemGitFake) GetItemContent(_ context.Context, args git.GetItemContentArgs) (io.ReadCloser, error) {
+	f.args = append(f.args, args)
+	content, ok := f.files[*args.RepositoryId+":"+*args.Path]
+	if !ok {
+		code := http.StatusNotFound
+		return nil, azuredevops.WrappedError{StatusCode: &code}
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func TestAzureProvider_ReadIdentifier(t *testing.T) {
+	origGit := newGitClient
+	t.Cleanup(func() { newGitClient = origGit })
+	fake := &itemGitFake{files: map[string]string{"repo-id:.boneclone.yaml": "accepts: [skel]\n"}}
+	newGitClient = func(context.Context, *azuredevops.Connection, *http.Client) (gitClient, error) { return fake, nil }
+
+	provider := &AzureRepositoryProvider{}
+	content, err := provider.ReadIdentifier(context.Background(), domain.GitRepository{Name: "Platform/api", ID: "repo-id"}, ".boneclone.yaml")
+	if err != nil || string(content) != "accepts: [skel]\n" {
+		t.Fatalf("unexpected identifier %q, err=%v", content, err)
+	}
+	if *fake.args[0].Project != "Platform" {
+		t.Fatalf("unexpected project: %s", *fake.args[0].Project)
+	}
+
+	_, err = provider.ReadIdentifier(context.Background(), domain.GitRepository{Name: "Platform/web"}, ".boneclone.yaml")
+	if !errors.Is(err, domain.ErrIdentifierNotFound) {
+		t.Fatalf("expected ErrIdentifierNotFound, got %v", err)
+	}
+}
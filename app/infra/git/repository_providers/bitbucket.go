@@ -0,0 +1,243 @@
+package repository_providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// DefaultBitbucketURL is the Bitbucket Cloud REST API used when providers[].baseUrl is not set.
+const DefaultBitbucketURL = "https://api.bitbucket.org/2.0"
+
+// bitbucketTokenUser is the clone username Bitbucket expects with repository, project and workspace access tokens.
+// Any other username is treated as the owner of an app password and sent with basic auth.
+const bitbucketTokenUser = "x-token-auth"
+
+const bitbucketCloneHTTPS = "https"
+
+type bitbucketPage[T any] struct {
+	Values []T    `json:"values"`
+	Next   string `json:"next"`
+}
+
+type bitbucketRepository struct {
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Links struct {
+		Clone []struct {
+			Name string `json:"name"`
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+type bitbucketBranch struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+}
+
+type bitbucketUser struct {
+	AccountID string `json:"account_id,omitempty"`
+	UUID      string `json:"uuid,omitempty"`
+	Nickname  string `json:"nickname,omitempty"`
+}
+
+type bitbucketPullRequest struct {
+	ID          int             `json:"id,omitempty"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Source      bitbucketBranch `json:"source"`
+	Destination bitbucketBranch `json:"destination"`
+	Reviewers   []bitbucketUser `json:"reviewers,omitempty"`
+	Links       struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type bitbucketMember struct {
+	User bitbucketUser `json:"user"`
+}
+
+type BitbucketRepositoryProvider struct {
+	client    *restClient
+	workspace string
+	pageSize  int
+}
+
+// GetRepositories lists every repository in the workspace, following the next links until the last page.
+// Names are repository slugs, as that is what the pull request API expects.
+func (b BitbucketRepositoryProvider) GetRepositories(ctx context.Context) (*[]domain.GitRepository, error) {
+	next := fmt.Sprintf("repositories/%s?pagelen=%d", url.PathEscape(b.workspace), pageSizeOrDefault(b.pageSize))
+
+	output := []domain.GitRepository{}
+	for next != "" {
+		page, err := callAPI(ctx, "list bitbucket repositories for "+b.workspace, func(ctx context.Context) (bitbucketPage[bitbucketRepository], error) {
+			var page bitbucketPage[bitbucketRepository]
+			err := b.client.do(ctx, http.MethodGet, next, nil, &page)
+			return page, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repo := range page.Values {
+			for _, link := range repo.Links.Clone {
+				if link.Name == bitbucketCloneHTTPS {
+					output = append(output, domain.GitRepository{Name: repo.Slug, Url: link.Href})
+					break
+				}
+			}
+		}
+		next = page.Next
+	}
+
+	return &output, nil
+}
+
+// CreatePullRequest opens a pull request from headBranch into baseBranch on the repository slug repo.
+func (b BitbucketRepositoryProvider) CreatePullRequest(ctx context.Context, repo, baseBranch, headBranch, title string, filesChanged []string, originalAuthor string, buildBody domain.PRBodyBuilder) (domain.PRInfo, error) {
+	body := ""
+	if buildBody != nil {
+		body = buildBody(repo, baseBranch, headBranch, filesChanged, originalAuthor)
+	}
+
+	newPR := bitbucketPullRequest{Title: title, Description: body}
+	newPR.Source.Branch.Name = headBranch
+	newPR.Destination.Branch.Name = baseBranch
+
+	pr, err := callAPI(ctx, "create bitbucket pull request for "+repo, func(ctx context.Context) (bitbucketPullRequest, error) {
+		var pr bitbucketPullRequest
+		err := b.client.do(ctx, http.MethodPost, b.pullRequestsPath(repo), newPR, &pr)
+		return pr, err
+	})
+	if err != nil {
+		return domain.PRInfo{}, err
+	}
+	return domain.PRInfo{ID: pr.ID, URL: pr.Links.HTML.Href}, nil
+}
+
+// AssignReviewers adds reviewers to an existing pull request. Reviewers may be given as account IDs,
+// UUIDs or nicknames and are resolved against the workspace members; unknown reviewers are ignored.
+func (b BitbucketRepositoryProvider) AssignReviewers(ctx context.Context, repo string, pr domain.PRInfo, reviewers []string) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+
+	members, err := b.workspaceMembers(ctx)
+	if err != nil {
+		return err
+	}
+	var wanted []bitbucketUser
+	seen := map[string]struct{}{}
+	for _, r := range reviewers {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		user, ok := matchBitbucketUser(members, r)
+		if !ok {
+			continue
+		}
+		if _, dup := seen[user.AccountID]; dup {
+			continue
+		}
+		seen[user.AccountID] = struct{}{}
+		wanted = append(wanted, bitbucketUser{AccountID: user.AccountID})
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	// Updating a pull request replaces its reviewers and requires the title, so start from the current state.
+	path := fmt.Sprintf("%s/%d", b.pullRequestsPath(repo), pr.ID)
+	current, err := callAPI(ctx, "get bitbucket pull request for "+repo, func(ctx context.Context) (bitbucketPullRequest, error) {
+		var current bitbucketPullRequest
+		err := b.client.do(ctx, http.MethodGet, path, nil, &current)
+		return current, err
+	})
+	if err != nil {
+		return err
+	}
+	update := struct {
+		Title     string          `json:"title"`
+		Reviewers []bitbucketUser `json:"reviewers"`
+	}{Title: current.Title}
+	for _, u := range current.Reviewers {
+		update.Reviewers = append(update.Reviewers, bitbucketUser{AccountID: u.AccountID})
+		delete(seen, u.AccountID)
+	}
+	for _, u := range wanted {
+		// Reviewers already on the pull request are kept once, from its current state.
+		if _, ok := seen[u.AccountID]; ok {
+			update.Reviewers = append(update.Reviewers, u)
+		}
+	}
+
+	_, err = callAPI(ctx, "assign bitbucket reviewers for "+repo, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, b.client.do(ctx, http.MethodPut, path, update, nil)
+	})
+	return err
+}
+
+func (b BitbucketRepositoryProvider) pullRequestsPath(repo string) string {
+	return fmt.Sprintf("repositories/%s/%s/pullrequests", url.PathEscape(b.workspace), url.PathEscape(repo))
+}
+
+// workspaceMembers lists every member of the workspace.
+func (b BitbucketRepositoryProvider) workspaceMembers(ctx context.Context) ([]bitbucketUser, error) {
+	next := fmt.Sprintf("workspaces/%s/members?pagelen=%d", url.PathEscape(b.workspace), DefaultPageSize)
+
+	var users []bitbucketUser
+	for next != "" {
+		page, err := callAPI(ctx, "list bitbucket members for "+b.workspace, func(ctx context.Context) (bitbucketPage[bitbucketMember], error) {
+			var page bitbucketPage[bitbucketMember]
+			err := b.client.do(ctx, http.MethodGet, next, nil, &page)
+			return page, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range page.Values {
+			users = append(users, m.User)
+		}
+		next = page.Next
+	}
+	return users, nil
+}
+
+// matchBitbucketUser finds the member whose account ID, UUID or nickname is reviewer.
+func matchBitbucketUser(members []bitbucketUser, reviewer string) (bitbucketUser, bool) {
+	if reviewer == "" {
+		return bitbucketUser{}, false
+	}
+	for _, m := range members {
+		if m.AccountID == reviewer || m.UUID == reviewer || strings.EqualFold(m.Nickname, reviewer) {
+			return m, true
+		}
+	}
+	return bitbucketUser{}, false
+}
+
+// NewBitbucketRepositoryProvider creates a provider for a Bitbucket Cloud workspace. config.Org is the workspace.
+// With config.Username set to anything but x-token-auth, config.Token is used as that user's app password;
+// otherwise it is sent as an access token.
+func NewBitbucketRepositoryProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBitbucketURL
+	}
+	auth := bearerAuth(config.Token)
+	if config.Username != "" && config.Username != bitbucketTokenUser {
+		auth = basicAuth(config.Username, config.Token)
+	}
+
+	client := newRestClient(baseURL, budgetFor(ProviderBitbucket, config.BaseURL, config.Token), auth)
+	return &BitbucketRepositoryProvider{client: client, workspace: config.Org, pageSize: config.PageSize}, nil
+}
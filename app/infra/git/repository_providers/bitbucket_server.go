@@ -0,0 +1,206 @@
+package repository_providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+const (
+	bitbucketServerAPIPath    = "rest/api/1.0"
+	bitbucketServerCloneHTTP  = "http"
+	bitbucketServerRefPrefix  = "refs/heads/"
+	bitbucketServerRoleReview = "REVIEWER"
+)
+
+type bitbucketServerPage[T any] struct {
+	Values        []T  `json:"values"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+type bitbucketServerProject struct {
+	Key string `json:"key"`
+}
+
+type bitbucketServerRepository struct {
+	Slug    string                 `json:"slug"`
+	Project bitbucketServerProject `json:"project"`
+	Links   struct {
+		Clone []struct {
+			Name string `json:"name"`
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+// bitbucketServerRef is a branch of a repository; pull request refs must name the repository as well as the branch.
+type bitbucketServerRef struct {
+	ID         string `json:"id"`
+	Repository struct {
+		Slug    string                 `json:"slug"`
+		Project bitbucketServerProject `json:"project"`
+	} `json:"repository"`
+}
+
+type bitbucketServerPullRequest struct {
+	ID          int                `json:"id,omitempty"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	FromRef     bitbucketServerRef `json:"fromRef"`
+	ToRef       bitbucketServerRef `json:"toRef"`
+	Links       struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+type bitbucketServerParticipant struct {
+	User struct {
+		Name string `json:"name"`
+	} `json:"user"`
+	Role string `json:"role"`
+}
+
+type BitbucketServerRepositoryProvider struct {
+	client   *restClient
+	project  string
+	pageSize int
+}
+
+// GetRepositories lists every repository in the configured project, or every repository the token can
+// see when no project is configured. Names are repository slugs, prefixed with the project key
+// ("KEY/slug") when listing all projects so pull requests can be opened in the right project.
+func (b BitbucketServerRepositoryProvider) GetRepositories(ctx context.Context) (*[]domain.GitRepository, error) {
+	path := "repos"
+	if b.project != "" {
+		path = fmt.Sprintf("projects/%s/repos", url.PathEscape(b.project))
+	}
+
+	output := []domain.GitRepository{}
+	for start := 0; ; {
+		query := fmt.Sprintf("%s?limit=%d&start=%d", path, pageSizeOrDefault(b.pageSize), start)
+		page, err := callAPI(ctx, "list bitbucket server repositories", func(ctx context.Context) (bitbucketServerPage[bitbucketServerRepository], error) {
+			var page bitbucketServerPage[bitbucketServerRepository]
+			err := b.client.do(ctx, http.MethodGet, query, nil, &page)
+			return page, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repo := range page.Values {
+			name := repo.Slug
+			if b.project == "" {
+				name = repo.Project.Key + "/" + repo.Slug
+			}
+			for _, link := range repo.Links.Clone {
+				if link.Name == bitbucketServerCloneHTTP {
+					output = append(output, domain.GitRepository{Name: name, Url: link.Href})
+					break
+				}
+			}
+		}
+
+		if page.IsLastPage || len(page.Values) == 0 {
+			break
+		}
+		start = page.NextPageStart
+	}
+
+	return &output, nil
+}
+
+// CreatePullRequest opens a pull request from headBranch into baseBranch.
+func (b BitbucketServerRepositoryProvider) CreatePullRequest(ctx context.Context, repo, baseBranch, headBranch, title string, filesChanged []string, originalAuthor string, buildBody domain.PRBodyBuilder) (domain.PRInfo, error) {
+	body := ""
+	if buildBody != nil {
+		body = buildBody(repo, baseBranch, headBranch, filesChanged, originalAuthor)
+	}
+
+	newPR := bitbucketServerPullRequest{
+		Title:       title,
+		Description: body,
+		FromRef:     b.branchRef(repo, headBranch),
+		ToRef:       b.branchRef(repo, baseBranch),
+	}
+
+	pr, err := callAPI(ctx, "create bitbucket server pull request for "+repo, func(ctx context.Context) (bitbucketServerPullRequest, error) {
+		var pr bitbucketServerPullRequest
+		err := b.client.do(ctx, http.MethodPost, b.pullRequestsPath(repo), newPR, &pr)
+		return pr, err
+	})
+	if err != nil {
+		return domain.PRInfo{}, err
+	}
+	url := ""
+	if len(pr.Links.Self) > 0 {
+		url = pr.Links.Self[0].Href
+	}
+	return domain.PRInfo{ID: pr.ID, URL: url}, nil
+}
+
+// AssignReviewers adds each username as a reviewer on an existing pull request.
+func (b BitbucketServerRepositoryProvider) AssignReviewers(ctx context.Context, repo string, pr domain.PRInfo, reviewers []string) error {
+	path := fmt.Sprintf("%s/%d/participants", b.pullRequestsPath(repo), pr.ID)
+	for _, r := range reviewers {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		participant := bitbucketServerParticipant{Role: bitbucketServerRoleReview}
+		participant.User.Name = r
+		_, err := callAPI(ctx, "add bitbucket server reviewer "+r+" for "+repo, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, b.client.do(ctx, http.MethodPost, path, participant, nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullRequestsPath returns the pull requests endpoint for repo, given as a slug in the configured
+// project or as "KEY/slug".
+func (b BitbucketServerRepositoryProvider) pullRequestsPath(repo string) string {
+	project, slug := b.projectAndSlug(repo)
+	return fmt.Sprintf("projects/%s/repos/%s/pull-requests", url.PathEscape(project), url.PathEscape(slug))
+}
+
+// branchRef returns the ref of branch in repo as pull requests expect it.
+func (b BitbucketServerRepositoryProvider) branchRef(repo, branch string) bitbucketServerRef {
+	ref := bitbucketServerRef{ID: bitbucketServerRefPrefix + branch}
+	ref.Repository.Project.Key, ref.Repository.Slug = b.projectAndSlug(repo)
+	return ref
+}
+
+// projectAndSlug splits a repository name into its project key and slug. Names are "KEY/slug" when listing
+// every project, otherwise the slug within the configured project.
+func (b BitbucketServerRepositoryProvider) projectAndSlug(repo string) (string, string) {
+	if key, slug, ok := strings.Cut(repo, "/"); ok {
+		return key, slug
+	}
+	return b.project, repo
+}
+
+// NewBitbucketServerRepositoryProvider creates a provider for a Bitbucket Server / Data Center instance at
+// config.BaseURL. config.Org is the project key; leave it empty to include every project.
+// config.Token is sent as a bearer token, or as the password of config.Username when one is set.
+func NewBitbucketServerRepositoryProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("bitbucket-server provider requires baseUrl")
+	}
+	auth := bearerAuth(config.Token)
+	if config.Username != "" {
+		auth = basicAuth(config.Username, config.Token)
+	}
+
+	baseURL := strings.TrimRight(config.BaseURL, "/") + "/" + bitbucketServerAPIPath
+	client := newRestClient(baseURL, budgetFor(ProviderBitbucketServer, config.BaseURL, config.Token), auth)
+	return &BitbucketServerRepositoryProvider{client: client, project: config.Org, pageSize: config.PageSize}, nil
+}
@@ -0,0 +1,111 @@
+package repository_providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+func newBitbucketServerStandIn(t *testing.T, reviewers *[]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/1.0/projects/PRJ/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") == "1" {
+			_, _ = fmt.Fprint(w, `{"isLastPage": true, "values": [{"slug": "two", "project": {"key": "PRJ"}, "links": {"clone": [{"name": "http", "href": "https://bitbucket.example.com/scm/prj/two.git"}]}}]}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"isLastPage": false, "nextPageStart": 1, "values": [{"slug": "one", "project": {"key": "PRJ"}, "links": {"clone": [{"name": "ssh", "href": "ssh://git@bitbucket.example.com:7999/prj/one.git"}, {"name": "http", "href": "https://bitbucket.example.com/scm/prj/one.git"}]}}]}`)
+	})
+	mux.HandleFunc("GET /rest/api/1.0/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"isLastPage": true, "values": [{"slug": "one", "project": {"key": "OTHER"}, "links": {"clone": [{"name": "http", "href": "https://bitbucket.example.com/scm/other/one.git"}]}}]}`)
+	})
+	mux.HandleFunc("POST /rest/api/1.0/projects/OTHER/repos/one/pull-requests", func(w http.ResponseWriter, r *http.Request) {
+		var pr bitbucketServerPullRequest
+		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+			t.Errorf("decode pull request: %v", err)
+		}
+		if pr.FromRef.ID != "refs/heads/boneclone/update" || pr.ToRef.ID != "refs/heads/main" {
+			t.Errorf("unexpected refs: %+v", pr)
+		}
+		for _, ref := range []bitbucketServerRef{pr.FromRef, pr.ToRef} {
+			if ref.Repository.Slug != "one" || ref.Repository.Project.Key != "OTHER" {
+				t.Errorf("unexpected ref repository: %+v", ref)
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprint(w, `{"id": 3, "links": {"self": [{"href": "https://bitbucket.example.com/projects/OTHER/repos/one/pull-requests/3"}]}}`)
+	})
+	mux.HandleFunc("POST /rest/api/1.0/projects/OTHER/repos/one/pull-requests/3/participants", func(w http.ResponseWriter, r *http.Request) {
+		var p bitbucketServerParticipant
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		if p.Role != bitbucketServerRoleReview {
+			t.Errorf("unexpected role: %s", p.Role)
+		}
+		*reviewers = append(*reviewers, p.User.Name)
+		_, _ = fmt.Fprint(w, `{}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBitbucketServerProvider_GetRepositories_Project(t *testing.T) {
+	srv := newBitbucketServerStandIn(t, &[]string{})
+	p, err := NewBitbucketServerRepositoryProvider(domain.ProviderConfig{Org: "PRJ", Token: "t", BaseURL: srv.URL + "/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	want := []domain.GitRepository{
+		{Name: "one", Url: "https://bitbucket.example.com/scm/prj/one.git"},
+		{Name: "two", Url: "https://bitbucket.example.com/scm/prj/two.git"},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
+	}
+}
+
+func TestBitbucketServerProvider_AllProjectsPullRequest(t *testing.T) {
+	var reviewers []string
+	srv := newBitbucketServerStandIn(t, &reviewers)
+	p, _ := NewBitbucketServerRepositoryProvider(domain.ProviderConfig{Token: "t", BaseURL: srv.URL})
+
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if len(*got) != 1 || (*got)[0].Name != "OTHER/one" {
+		t.Fatalf("expected project-qualified name, got %#v", *got)
+	}
+
+	prMgr := p.(domain.PullRequestManager)
+	pr, err := prMgr.CreatePullRequest(context.Background(), (*got)[0].Name, "main", "boneclone/update", "Update", nil, "", nil)
+	if err != nil {
+		t.Fatalf("CreatePullRequest unexpected error: %v", err)
+	}
+	if pr.ID != 3 || pr.URL == "" {
+		t.Fatalf("unexpected PR info: %+v", pr)
+	}
+	if err := prMgr.AssignReviewers(context.Background(), (*got)[0].Name, pr, []string{"alice", " ", "bob"}); err != nil {
+		t.Fatalf("AssignReviewers unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(reviewers, []string{"alice", "bob"}) {
+		t.Fatalf("unexpected reviewers: %v", reviewers)
+	}
+}
+
+func TestNewBitbucketServerRepositoryProvider_RequiresBaseURL(t *testing.T) {
+	if _, err := NewBitbucketServerRepositoryProvider(domain.ProviderConfig{Org: "PRJ", Token: "t"}); err == nil {
+		t.Fatalf("expected error without baseUrl")
+	}
+}
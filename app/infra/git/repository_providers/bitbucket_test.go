@@ -0,0 +1,119 @@
+package repository_providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// newBitbucketStandIn serves the subset of the Bitbucket Cloud API the provider uses and records PR updates.
+func newBitbucketStandIn(t *testing.T, updates *[]map[string]any) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repositories/ws", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = fmt.Fprint(w, `{"values": [{"name": "Repo Two", "slug": "repo-two", "links": {"clone": [{"name": "https", "href": "https://bitbucket.org/ws/repo-two.git"}]}}]}`)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"values": [{"name": "Repo One", "slug": "repo-one", "links": {"clone": [{"name": "ssh", "href": "git@bitbucket.org:ws/repo-one.git"}, {"name": "https", "href": "https://bitbucket.org/ws/repo-one.git"}]}}], "next": "%s/repositories/ws?page=2"}`, srv.URL)
+	})
+	mux.HandleFunc("POST /repositories/ws/repo-one/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		var pr bitbucketPullRequest
+		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+			t.Errorf("decode pull request: %v", err)
+		}
+		if pr.Source.Branch.Name != "boneclone/update" || pr.Destination.Branch.Name != "main" || pr.Title != "Update" {
+			t.Errorf("unexpected pull request: %+v", pr)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprint(w, `{"id": 7, "title": "Update", "links": {"html": {"href": "https://bitbucket.org/ws/repo-one/pull-requests/7"}}}`)
+	})
+	mux.HandleFunc("GET /workspaces/ws/members", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"values": [{"user": {"account_id": "acc-1", "uuid": "{u-1}", "nickname": "alice"}}, {"user": {"account_id": "acc-2", "uuid": "{u-2}", "nickname": "bob"}}]}`)
+	})
+	mux.HandleFunc("GET /repositories/ws/repo-one/pullrequests/7", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"id": 7, "title": "Update", "reviewers": []}`)
+	})
+	mux.HandleFunc("PUT /repositories/ws/repo-one/pullrequests/7", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		*updates = append(*updates, body)
+		_, _ = fmt.Fprint(w, `{"id": 7}`)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBitbucketProvider_GetRepositories_Paginates(t *testing.T) {
+	srv := newBitbucketStandIn(t, &[]map[string]any{})
+	p, err := NewBitbucketRepositoryProvider(domain.ProviderConfig{Provider: ProviderBitbucket, Org: "ws", Token: "t", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	want := []domain.GitRepository{
+		{Name: "repo-one", Url: "https://bitbucket.org/ws/repo-one.git"},
+		{Name: "repo-two", Url: "https://bitbucket.org/ws/repo-two.git"},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
+	}
+}
+
+func TestBitbucketProvider_CreatePullRequestAndAssignReviewers(t *testing.T) {
+	var updates []map[string]any
+	srv := newBitbucketStandIn(t, &updates)
+	p, _ := NewBitbucketRepositoryProvider(domain.ProviderConfig{Provider: ProviderBitbucket, Org: "ws", Token: "t", BaseURL: srv.URL})
+	prMgr := p.(domain.PullRequestManager)
+
+	pr, err := prMgr.CreatePullRequest(context.Background(), "repo-one", "main", "boneclone/update", "Update", nil, "", domain.DefaultPRBodyBuilder)
+	if err != nil {
+		t.Fatalf("CreatePullRequest unexpected error: %v", err)
+	}
+	if pr.ID != 7 || pr.URL != "https://bitbucket.org/ws/repo-one/pull-requests/7" {
+		t.Fatalf("unexpected PR info: %+v", pr)
+	}
+
+	if err := prMgr.AssignReviewers(context.Background(), "repo-one", pr, []string{"alice", " acc-2 ", "", "unknown", "acc-2", "alice"}); err != nil {
+		t.Fatalf("AssignReviewers unexpected error: %v", err)
+	}
+	if len(updates) != 1 {
+		t.Fatalf("expected one update, got %d", len(updates))
+	}
+	want := []any{map[string]any{"account_id": "acc-1"}, map[string]any{"account_id": "acc-2"}}
+	if !reflect.DeepEqual(updates[0]["reviewers"], want) || updates[0]["title"] != "Update" {
+		t.Fatalf("unexpected update body: %v", updates[0])
+	}
+}
+
+func TestNewBitbucketRepositoryProvider_Auth(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		_, _ = fmt.Fprint(w, `{"values": []}`)
+	}))
+	defer srv.Close()
+
+	for _, username := range []string{"", bitbucketTokenUser, "alice"} {
+		p, _ := NewBitbucketRepositoryProvider(domain.ProviderConfig{Org: "ws", Username: username, Token: "secret", BaseURL: srv.URL})
+		if _, err := p.GetRepositories(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	want := []string{"Bearer secret", "Bearer secret", "Basic YWxpY2U6c2VjcmV0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected authorization headers: %v", got)
+	}
+}
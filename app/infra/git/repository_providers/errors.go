@@ -0,0 +1,115 @@
+package repository_providers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// classifyAPIError marks provider API errors worth retrying (5xx, 408, 429, rate limits, dropped connections
+// and timeouts) as transient. Rate limited errors carry the wait until the limit resets.
+// Client errors such as 401, 404 or 422 and cancellation are permanent.
+func classifyAPIError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if d, ok := apiRateLimitWait(err, time.Now()); ok {
+		return domain.TransientAfter(err, d)
+	}
+	if code, ok := apiStatusCode(err); ok {
+		if domain.IsTransientStatus(code) {
+			return domain.Transient(err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return domain.Transient(err)
+	}
+	return err
+}
+
+// apiRateLimitWait reports whether err is a rate limit response and how long to wait before retrying it.
+func apiRateLimitWait(err error, now time.Time) (time.Duration, bool) {
+	var rlErr *github.RateLimitError
+	if errors.As(err, &rlErr) {
+		return rlErr.Rate.Reset.Sub(now), true
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		d, _ := rateLimitWait(abuseErr.Response, now)
+		return d, true
+	}
+
+	var resp *http.Response
+	var ghErr *github.ErrorResponse
+	var glErr *gitlab.ErrorResponse
+	var restErr *restError
+	switch {
+	case errors.As(err, &ghErr):
+		resp = ghErr.Response
+	case errors.As(err, &glErr):
+		resp = glErr.Response
+	case errors.As(err, &restErr):
+		resp = restErr.Response
+	}
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusForbidden) {
+		return 0, false
+	}
+	return rateLimitWait(resp, now)
+}
+
+// apiStatusCode extracts the HTTP status code from the error types returned by the provider SDKs.
+func apiStatusCode(err error) (int, bool) {
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil {
+		return ghErr.Response.StatusCode, true
+	}
+	var glErr *gitlab.ErrorResponse
+	if errors.As(err, &glErr) && glErr.Response != nil {
+		return glErr.Response.StatusCode, true
+	}
+	var restErr *restError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		return restErr.Response.StatusCode, true
+	}
+	var azErr azuredevops.WrappedError
+	if errors.As(err, &azErr) && azErr.StatusCode != nil {
+		return *azErr.StatusCode, true
+	}
+	var azErrPtr *azuredevops.WrappedError
+	if errors.As(err, &azErrPtr) && azErrPtr.StatusCode != nil {
+		return *azErrPtr.StatusCode, true
+	}
+	return 0, false
+}
+
+// isNotFound reports whether err is a 404 response from a provider API.
+func isNotFound(err error) bool {
+	code, ok := apiStatusCode(err)
+	return ok && code == http.StatusNotFound
+}
+
+// callAPI runs a provider API call with the retry policy from ctx, classifying its errors first.
+func callAPI[T any](ctx context.Context, op string, fn func(context.Context) (T, error)) (T, error) {
+	var out T
+	err := domain.Retry(ctx, op, func(ctx context.Context) error {
+		var err error
+		out, err = fn(ctx)
+		return classifyAPIError(err)
+	})
+	return out, err
+}
@@ -0,0 +1,50 @@
+package repository_providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+func TestClassifyAPIError(t *testing.T) {
+	ghErr := func(code int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: code}}
+	}
+	azErr := func(code int) error {
+		return azuredevops.WrappedError{StatusCode: &code}
+	}
+
+	cases := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{name: "github 500", err: ghErr(http.StatusInternalServerError), transient: true},
+		{name: "github 429", err: ghErr(http.StatusTooManyRequests), transient: true},
+		{name: "github 404", err: ghErr(http.StatusNotFound), transient: false},
+		{name: "github 401", err: ghErr(http.StatusUnauthorized), transient: false},
+		{name: "azure 503", err: azErr(http.StatusServiceUnavailable), transient: true},
+		{name: "azure 400", err: azErr(http.StatusBadRequest), transient: false},
+		{name: "rest 502", err: &restError{Response: &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}}, transient: true},
+		{name: "rest 404", err: &restError{Response: &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}}}, transient: false},
+		{name: "canceled", err: context.Canceled, transient: false},
+		{name: "plain error", err: errors.New("boom"), transient: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := domain.IsTransient(classifyAPIError(tc.err)); got != tc.transient {
+				t.Fatalf("expected transient=%v, got %v", tc.transient, got)
+			}
+		})
+	}
+
+	if classifyAPIError(nil) != nil {
+		t.Fatalf("expected nil error to stay nil")
+	}
+}
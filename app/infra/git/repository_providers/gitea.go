@@ -0,0 +1,123 @@
+package repository_providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+const giteaAPIPath = "api/v1"
+
+type giteaRepository struct {
+	Name     string `json:"name"`
+	CloneURL string `json:"clone_url"`
+}
+
+type giteaPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+type GiteaRepositoryProvider struct {
+	client   *restClient
+	org      string
+	user     bool
+	pageSize int
+}
+
+// GetRepositories lists every repository in the org, or owned by the user for user namespaces. Gitea caps
+// the page size server side, so pages are requested until an empty one is returned.
+func (g GiteaRepositoryProvider) GetRepositories(ctx context.Context) (*[]domain.GitRepository, error) {
+	namespace := "orgs"
+	if g.user {
+		namespace = "users"
+	}
+
+	output := []domain.GitRepository{}
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("%s/%s/repos?limit=%d&page=%d", namespace, url.PathEscape(g.org), pageSizeOrDefault(g.pageSize), page)
+		repos, err := callAPI(ctx, "list gitea repositories for "+g.org, func(ctx context.Context) ([]giteaRepository, error) {
+			var repos []giteaRepository
+			err := g.client.do(ctx, http.MethodGet, path, nil, &repos)
+			return repos, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(repos) == 0 {
+			break
+		}
+		for _, repo := range repos {
+			output = append(output, domain.GitRepository{Name: repo.Name, Url: repo.CloneURL})
+		}
+	}
+
+	return &output, nil
+}
+
+// CreatePullRequest opens a pull request from headBranch into baseBranch on the org's repository repo.
+func (g GiteaRepositoryProvider) CreatePullRequest(ctx context.Context, repo, baseBranch, headBranch, title string, filesChanged []string, originalAuthor string, buildBody domain.PRBodyBuilder) (domain.PRInfo, error) {
+	body := ""
+	if buildBody != nil {
+		body = buildBody(repo, baseBranch, headBranch, filesChanged, originalAuthor)
+	}
+
+	newPR := struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+		Head  string `json:"head"`
+		Base  string `json:"base"`
+	}{Title: title, Body: body, Head: headBranch, Base: baseBranch}
+
+	pr, err := callAPI(ctx, "create gitea pull request for "+repo, func(ctx context.Context) (giteaPullRequest, error) {
+		var pr giteaPullRequest
+		err := g.client.do(ctx, http.MethodPost, g.pullsPath(repo), newPR, &pr)
+		return pr, err
+	})
+	if err != nil {
+		return domain.PRInfo{}, err
+	}
+	return domain.PRInfo{ID: pr.Number, URL: pr.HTMLURL}, nil
+}
+
+// AssignReviewers requests reviews from the given usernames on an existing pull request.
+func (g GiteaRepositoryProvider) AssignReviewers(ctx context.Context, repo string, pr domain.PRInfo, reviewers []string) error {
+	var names []string
+	for _, r := range reviewers {
+		if r = strings.TrimSpace(r); r != "" {
+			names = append(names, r)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	req := struct {
+		Reviewers []string `json:"reviewers"`
+	}{Reviewers: names}
+	path := fmt.Sprintf("%s/%d/requested_reviewers", g.pullsPath(repo), pr.ID)
+	_, err := callAPI(ctx, "request gitea reviewers for "+repo, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, g.client.do(ctx, http.MethodPost, path, req, nil)
+	})
+	return err
+}
+
+func (g GiteaRepositoryProvider) pullsPath(repo string) string {
+	return fmt.Sprintf("repos/%s/%s/pulls", url.PathEscape(g.org), url.PathEscape(repo))
+}
+
+// NewGiteaRepositoryProvider creates a provider for the Gitea or Forgejo instance at config.BaseURL.
+func NewGiteaRepositoryProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("gitea provider requires baseUrl")
+	}
+	auth := func(req *http.Request) { req.Header.Set("Authorization", "token "+config.Token) }
+
+	baseURL := strings.TrimRight(config.BaseURL, "/") + "/" + giteaAPIPath
+	client := newRestClient(baseURL, budgetFor(ProviderGitea, config.BaseURL, config.Token), auth)
+	return &GiteaRepositoryProvider{client: client, org: config.Org, user: config.UserNamespace(), pageSize: config.PageSize}, nil
+}
@@ -0,0 +1,114 @@
+package repository_providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+func TestGiteaProvider_GetRepositories_Paginates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/orgs/tools/repos" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("unexpected authorization header: %q", got)
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			_, _ = fmt.Fprint(w, `[{"name": "one", "clone_url": "https://git.example.com/tools/one.git"}]`)
+		case "2":
+			_, _ = fmt.Fprint(w, `[{"name": "two", "clone_url": "https://git.example.com/tools/two.git"}]`)
+		default:
+			_, _ = fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewGiteaRepositoryProvider(domain.ProviderConfig{Org: "tools", Token: "secret", BaseURL: srv.URL, PageSize: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	want := []domain.GitRepository{
+		{Name: "one", Url: "https://git.example.com/tools/one.git"},
+		{Name: "two", Url: "https://git.example.com/tools/two.git"},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
+	}
+}
+
+func TestGiteaProvider_CreatePullRequestAndAssignReviewers(t *testing.T) {
+	var requested []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/repos/tools/one/pulls", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["head"] != "boneclone/update" || body["base"] != "main" || body["title"] != "Update" {
+			t.Errorf("unexpected pull request: %v", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprint(w, `{"number": 12, "html_url": "https://git.example.com/tools/one/pulls/12"}`)
+	})
+	mux.HandleFunc("POST /api/v1/repos/tools/one/pulls/12/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Reviewers []string `json:"reviewers"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requested = body.Reviewers
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprint(w, `[]`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p, _ := NewGiteaRepositoryProvider(domain.ProviderConfig{Org: "tools", Token: "secret", BaseURL: srv.URL})
+	prMgr := p.(domain.PullRequestManager)
+
+	pr, err := prMgr.CreatePullRequest(context.Background(), "one", "main", "boneclone/update", "Update", nil, "", domain.DefaultPRBodyBuilder)
+	if err != nil {
+		t.Fatalf("CreatePullRequest unexpected error: %v", err)
+	}
+	if pr.ID != 12 || pr.URL != "https://git.example.com/tools/one/pulls/12" {
+		t.Fatalf("unexpected PR info: %+v", pr)
+	}
+	if err := prMgr.AssignReviewers(context.Background(), "one", pr, []string{"alice", ""}); err != nil {
+		t.Fatalf("AssignReviewers unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(requested, []string{"alice"}) {
+		t.Fatalf("unexpected reviewers: %v", requested)
+	}
+}
+
+func TestGiteaProvider_GetRepositories_UserNamespace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/users/alice/repos" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("page") == "1" {
+			_, _ = fmt.Fprint(w, `[{"name": "dotfiles", "clone_url": "https://git.example.com/alice/dotfiles.git"}]`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `[]`)
+	}))
+	defer srv.Close()
+
+	p, _ := NewGiteaRepositoryProvider(domain.ProviderConfig{Users: []string{"alice"}, Token: "secret", BaseURL: srv.URL}.Namespaces()[0])
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if len(*got) != 1 || (*got)[0].Name != "dotfiles" {
+		t.Fatalf("unexpected repositories: %#v", *got)
+	}
+}
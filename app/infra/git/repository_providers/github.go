@@ -2,33 +2,237 @@ package repository_providers
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/google/go-github/v72/github"
 
 	"go.iain.rocks/boneclone/app/domain"
 )
 
+// githubSearchPageSize is the largest page the search API returns.
+const githubSearchPageSize = 100
+
 type GithubRepositoryProvider struct {
-	github  *github.Client
-	orgName string
+	github   *github.Client
+	orgName  string
+	user     bool
+	pageSize int
+	filter   *domain.RepositoryMatcher
 }
 
-func (g GithubRepositoryProvider) GetRepositories() (*[]domain.GitRepository, error) {
-	repos, _, err := g.github.Repositories.ListByOrg(context.Background(), g.orgName, nil)
-	if err != nil {
-		return nil, err
+// githubPageLister lists one page of repositories.
+type githubPageLister func(ctx context.Context, page int) ([]*github.Repository, *github.Response, error)
+
+// GetRepositories lists every repository in the org, or owned by the user for user namespaces, following
+// pagination until the last page, and drops those skipped by the provider's filter.
+func (g GithubRepositoryProvider) GetRepositories(ctx context.Context) (*[]domain.GitRepository, error) {
+	list := g.pageLister(ctx)
+
+	var allRepos []*github.Repository
+	for page := 1; ; {
+		var resp *github.Response
+		repos, err := callAPI(ctx, "list github repositories for "+g.orgName, func(ctx context.Context) ([]*github.Repository, error) {
+			repos, r, err := list(ctx, page)
+			resp = r
+			return repos, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		allRepos = append(allRepos, repos...)
+
+		if resp.NextPage == 0 {
+			break // No more pages
+		}
+		page = resp.NextPage
 	}
+
 	output := []domain.GitRepository{}
-	for _, repo := range repos {
-		output = append(output, domain.GitRepository{
-			Name: *repo.Name,
-			Url:  *repo.CloneURL,
+	for _, repo := range allRepos {
+		output = append(output, githubRepository(repo))
+	}
+	output = g.filter.Filter(output)
+
+	return &output, nil
+}
+
+// pageLister picks the endpoint listing the namespace's repositories. A user's private repositories are
+// only listed by /user/repos, so that is used when the namespace is the authenticated user.
+func (g GithubRepositoryProvider) pageLister(ctx context.Context) githubPageLister {
+	listOpts := func(page int) github.ListOptions {
+		return github.ListOptions{PerPage: pageSizeOrDefault(g.pageSize), Page: page}
+	}
+
+	if !g.user {
+		opts := &github.RepositoryListByOrgOptions{}
+		if g.filter.SkipsForks() {
+			// Sources are the org's repositories that are not forks, so forks are never listed.
+			opts.Type = "sources"
+		}
+		return func(ctx context.Context, page int) ([]*github.Repository, *github.Response, error) {
+			opts.ListOptions = listOpts(page)
+			return g.github.Repositories.ListByOrg(ctx, g.orgName, opts)
+		}
+	}
+
+	if g.isAuthenticatedUser(ctx) {
+		opts := &github.RepositoryListByAuthenticatedUserOptions{Affiliation: "owner"}
+		return func(ctx context.Context, page int) ([]*github.Repository, *github.Response, error) {
+			opts.ListOptions = listOpts(page)
+			return g.github.Repositories.ListByAuthenticatedUser(ctx, opts)
+		}
+	}
+	opts := &github.RepositoryListByUserOptions{Type: "owner"}
+	return func(ctx context.Context, page int) ([]*github.Repository, *github.Response, error) {
+		opts.ListOptions = listOpts(page)
+		return g.github.Repositories.ListByUser(ctx, g.orgName, opts)
+	}
+}
+
+// isAuthenticatedUser reports whether the namespace is the user the token belongs to. Tokens that cannot
+// read /user, such as GitHub App installation tokens, are treated as belonging to someone else.
+func (g GithubRepositoryProvider) isAuthenticatedUser(ctx context.Context) bool {
+	user, err := callAPI(ctx, "get authenticated github user", func(ctx context.Context) (*github.User, error) {
+		user, _, err := g.github.Users.Get(ctx, "")
+		return user, err
+	})
+	return err == nil && strings.EqualFold(user.GetLogin(), g.orgName)
+}
+
+// SearchRepositories finds the org's (or user's) repositories containing the identifier file through code search, then
+// loads each one for its metadata and drops those skipped by the provider's filter. Code search never returns forks
+// and stops after 1000 results, so searching without filter.skipForks, and incomplete or truncated results, are
+// errors and discovery falls back to listing every repository.
+func (g GithubRepositoryProvider) SearchRepositories(ctx context.Context, identifier string) (*[]domain.GitRepository, error) {
+	if !g.filter.SkipsForks() {
+		return nil, fmt.Errorf("github code search does not return forks, set filter.skipForks to search %s", g.orgName)
+	}
+	qualifier := "org"
+	if g.user {
+		qualifier = "user"
+	}
+	query := fmt.Sprintf("filename:%s %s:%s", path.Base(identifier), qualifier, g.orgName)
+	if dir := path.Dir(identifier); dir != "." {
+		query += " path:" + dir
+	}
+
+	opts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: githubSearchPageSize, Page: 1}}
+	var found []*github.Repository
+	seen := map[string]struct{}{}
+	paged := 0
+	for {
+		var resp *github.Response
+		result, err := callAPI(ctx, "search github code for "+g.orgName, func(ctx context.Context) (*github.CodeSearchResult, error) {
+			result, r, err := g.github.Search.Code(ctx, query, opts)
+			resp = r
+			return result, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result.GetIncompleteResults() {
+			return nil, fmt.Errorf("github code search returned incomplete results for %s", g.orgName)
+		}
+		paged += len(result.CodeResults)
+		if resp.NextPage == 0 && result.GetTotal() > paged {
+			return nil, fmt.Errorf("github code search found %d files for %s but only returned %d", result.GetTotal(), g.orgName, paged)
+		}
+
+		for _, code := range result.CodeResults {
+			repo := code.GetRepository()
+			if repo == nil {
+				continue
+			}
+			if _, ok := seen[repo.GetFullName()]; ok {
+				continue
+			}
+			seen[repo.GetFullName()] = struct{}{}
+			found = append(found, repo)
+		}
+
+		if resp.NextPage == 0 {
+			break // No more pages
+		}
+		opts.Page = resp.NextPage
+	}
+
+	output := []domain.GitRepository{}
+	for _, partial := range found {
+		owner, name := partial.GetOwner().GetLogin(), partial.GetName()
+		repo, err := callAPI(ctx, "get github repository "+owner+"/"+name, func(ctx context.Context) (*github.Repository, error) {
+			repo, _, err := g.github.Repositories.Get(ctx, owner, name)
+			return repo, err
 		})
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, githubRepository(repo))
 	}
+	output = g.filter.Filter(output)
 
 	return &output, nil
 }
 
+// ReadIdentifier reads path from the repository's default branch through the contents API.
+func (g GithubRepositoryProvider) ReadIdentifier(ctx context.Context, repo domain.GitRepository, path string) ([]byte, error) {
+	owner := repo.Owner
+	if owner == "" {
+		owner = g.orgName
+	}
+	file, err := callAPI(ctx, "read github "+path+" for "+repo.Name, func(ctx context.Context) (*github.RepositoryContent, error) {
+		file, _, _, err := g.github.Repositories.GetContents(ctx, owner, repo.Name, path, nil)
+		return file, err
+	})
+	if isNotFound(err) {
+		return nil, domain.ErrIdentifierNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		// The path is a directory.
+		return nil, domain.ErrIdentifierNotFound
+	}
+	content, err := file.GetContent()
+	return []byte(content), err
+}
+
+// githubRepository converts a repository listed by the GitHub API.
+func githubRepository(repo *github.Repository) domain.GitRepository {
+	visibility := repo.GetVisibility()
+	if visibility == "" && repo.Private != nil {
+		visibility = domain.VisibilityPublic
+		if repo.GetPrivate() {
+			visibility = domain.VisibilityPrivate
+		}
+	}
+	lastActivity := repo.GetPushedAt().Time
+	if lastActivity.IsZero() {
+		lastActivity = repo.GetUpdatedAt().Time
+	}
+	id := ""
+	if repo.ID != nil {
+		id = strconv.FormatInt(repo.GetID(), 10)
+	}
+	return domain.GitRepository{
+		Name:          repo.GetName(),
+		Url:           repo.GetCloneURL(),
+		Owner:         repo.GetOwner().GetLogin(),
+		ID:            id,
+		DefaultBranch: repo.GetDefaultBranch(),
+		Archived:      repo.GetArchived(),
+		Fork:          repo.GetFork(),
+		Visibility:    visibility,
+		Topics:        repo.Topics,
+		LastActivity:  lastActivity,
+	}
+}
+
 // CreatePullRequest creates a PR on the specified repository within the configured org.
 // The PR body is produced by the provided buildBody function.
 func (g GithubRepositoryProvider) CreatePullRequest(ctx context.Context, repo, baseBranch, headBranch, title string, filesChanged []string, originalAuthor string, buildBody domain.PRBodyBuilder) (domain.PRInfo, error) {
@@ -44,7 +248,10 @@ func (g GithubRepositoryProvider) CreatePullRequest(ctx context.Context, repo, b
 		Body:  github.Ptr(body),
 	}
 
-	pr, _, err := g.github.PullRequests.Create(ctx, g.orgName, repo, newPR)
+	pr, err := callAPI(ctx, "create github pull request for "+repo, func(ctx context.Context) (*github.PullRequest, error) {
+		pr, _, err := g.github.PullRequests.Create(ctx, g.orgName, repo, newPR)
+		return pr, err
+	})
 	if err != nil {
 		return domain.PRInfo{}, err
 	}
@@ -65,12 +272,48 @@ func (g GithubRepositoryProvider) AssignReviewers(ctx context.Context, repo stri
 		return nil
 	}
 	req := github.ReviewersRequest{Reviewers: reviewers}
-	_, _, err := g.github.PullRequests.RequestReviewers(ctx, g.orgName, repo, pr.ID, req)
+	_, err := callAPI(ctx, "request github reviewers for "+repo, func(ctx context.Context) (*github.PullRequest, error) {
+		pr, _, err := g.github.PullRequests.RequestReviewers(ctx, g.orgName, repo, pr.ID, req)
+		return pr, err
+	})
 	return err
 }
 
-func NewGithubRepositoryProvider(token, orgName string) (domain.GitRepositoryProvider, error) {
-	client := github.NewClient(nil).WithAuthToken(token)
+// NewGithubRepositoryProvider creates a provider for github.com, or for a GitHub Enterprise Server
+// instance when config.BaseURL is set. It authenticates as a GitHub App installation when config.App
+// is configured, and with config.Token otherwise.
+func NewGithubRepositoryProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+	var client *github.Client
+	if config.App.Configured() {
+		source, err := githubAppSourceFor(config)
+		if err != nil {
+			return nil, err
+		}
+		client = github.NewClient(&http.Client{Transport: &authTransport{
+			header: source.installationHeader,
+			base:   newRateLimitedClient(budgetFor(ProviderGithub, config.BaseURL, githubAppBudgetKey(config))).Transport,
+		}})
+	} else {
+		client = github.NewClient(newRateLimitedClient(budgetFor(ProviderGithub, config.BaseURL, config.Token))).WithAuthToken(config.Token)
+	}
+	if config.BaseURL != "" {
+		uploadURL := config.UploadURL
+		if uploadURL == "" {
+			uploadURL = config.BaseURL
+		}
+		var err error
+		client, err = client.WithEnterpriseURLs(config.BaseURL, uploadURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid github enterprise url: %w", err)
+		}
+	}
 
-	return &GithubRepositoryProvider{client, orgName}, nil
+	filter, err := domain.NewRepositoryMatcher(config.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github filter: %w", err)
+	}
+	if problems := validateDiscovery("discovery", ProviderGithub, config); len(problems) > 0 {
+		return nil, fmt.Errorf("invalid github discovery: %s", problems[0].Message)
+	}
+	return &GithubRepositoryProvider{github: client, orgName: config.Org, user: config.UserNamespace(), pageSize: config.PageSize, filter: filter}, nil
 }
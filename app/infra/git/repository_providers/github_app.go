@@ -0,0 +1,222 @@
+package repository_providers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// GitHub App token timings. Installation tokens are valid for an hour; they are replaced once less
+// than githubAppTokenRefresh remains so a clone or push never starts with a token about to expire.
+const (
+	githubAppJWTLifetime  = 9 * time.Minute
+	githubAppClockSkew    = time.Minute
+	githubAppTokenRefresh = 5 * time.Minute
+)
+
+// githubAppGitUser is the username GitHub expects with an installation token over HTTPS.
+const githubAppGitUser = "x-access-token"
+
+// githubAppTokenSource issues installation access tokens for a GitHub App, caching each until it nears expiry.
+type githubAppTokenSource struct {
+	config domain.ProviderConfig
+	key    *rsa.PrivateKey
+	apps   *github.Client
+	now    func() time.Time
+
+	mu             sync.Mutex
+	installationID int64
+	token          string
+	expires        time.Time
+}
+
+var (
+	githubAppSourcesMu sync.Mutex
+	githubAppSources   = map[string]*githubAppTokenSource{}
+)
+
+// githubAppSourceFor returns the shared token source for the app configured on config. Sources live at package
+// level so every provider and git operation for the same installation reuses one token.
+func githubAppSourceFor(config domain.ProviderConfig) (*githubAppTokenSource, error) {
+	githubAppSourcesMu.Lock()
+	defer githubAppSourcesMu.Unlock()
+
+	key := fmt.Sprintf("%s\x00%d\x00%d\x00%s\x00%t\x00%s", config.BaseURL, config.App.AppID, config.App.InstallationID, config.Org, config.UserNamespace(), config.App.PrivateKeyFile)
+	if s, ok := githubAppSources[key]; ok {
+		return s, nil
+	}
+	s, err := newGithubAppTokenSource(config)
+	if err != nil {
+		return nil, err
+	}
+	githubAppSources[key] = s
+	return s, nil
+}
+
+func newGithubAppTokenSource(config domain.ProviderConfig) (*githubAppTokenSource, error) {
+	pemData, err := os.ReadFile(config.App.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading github app private key: %w", err)
+	}
+	key, err := parseRSAPrivateKey(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("parsing github app private key %s: %w", config.App.PrivateKeyFile, err)
+	}
+
+	s := &githubAppTokenSource{config: config, key: key, now: time.Now, installationID: config.App.InstallationID}
+	apps := github.NewClient(&http.Client{Transport: &authTransport{
+		header: s.jwtHeader,
+		base:   newRateLimitedClient(budgetFor(ProviderGithub, config.BaseURL, githubAppBudgetKey(config))).Transport,
+	}})
+	if config.BaseURL != "" {
+		uploadURL := config.UploadURL
+		if uploadURL == "" {
+			uploadURL = config.BaseURL
+		}
+		if apps, err = apps.WithEnterpriseURLs(config.BaseURL, uploadURL); err != nil {
+			return nil, fmt.Errorf("invalid github enterprise url: %w", err)
+		}
+	}
+	s.apps = apps
+	return s, nil
+}
+
+// Token returns a valid installation token, creating a new one when there is none or it is about to expire.
+// Without a configured installation ID the app's installation on the org (or user namespace) is used.
+func (s *githubAppTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Add(githubAppTokenRefresh).Before(s.expires) {
+		return s.token, nil
+	}
+
+	if s.installationID == 0 {
+		inst, err := callAPI(ctx, "find github app installation for "+s.config.Org, func(ctx context.Context) (*github.Installation, error) {
+			if s.config.UserNamespace() {
+				inst, _, err := s.apps.Apps.FindUserInstallation(ctx, s.config.Org)
+				return inst, err
+			}
+			inst, _, err := s.apps.Apps.FindOrganizationInstallation(ctx, s.config.Org)
+			return inst, err
+		})
+		if err != nil {
+			return "", fmt.Errorf("finding github app installation for %s: %w", s.config.Org, err)
+		}
+		s.installationID = inst.GetID()
+	}
+
+	tok, err := callAPI(ctx, "create github app installation token", func(ctx context.Context) (*github.InstallationToken, error) {
+		tok, _, err := s.apps.Apps.CreateInstallationToken(ctx, s.installationID, nil)
+		return tok, err
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating github app installation token: %w", err)
+	}
+	s.token = tok.GetToken()
+	s.expires = tok.GetExpiresAt().Time
+	return s.token, nil
+}
+
+// jwtHeader returns the Authorization header for app-level endpoints: a short-lived RS256 JWT issued by the app.
+func (s *githubAppTokenSource) jwtHeader(_ context.Context) (string, error) {
+	now := s.now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]int64{
+		"iat": now.Add(-githubAppClockSkew).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": s.config.App.AppID,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// installationHeader returns the Authorization header for API calls made as the installation.
+func (s *githubAppTokenSource) installationHeader(ctx context.Context) (string, error) {
+	token, err := s.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "token " + token, nil
+}
+
+// authTransport sets the Authorization header from header on every request, so tokens can change during a run.
+type authTransport struct {
+	header func(context.Context) (string, error)
+	base   http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	value, err := t.header(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", value)
+	return t.base.RoundTrip(req)
+}
+
+// githubAppBudgetKey identifies the rate limit budget of an app installation, which is separate from any token's.
+func githubAppBudgetKey(config domain.ProviderConfig) string {
+	return fmt.Sprintf("app:%d:%d:%s", config.App.AppID, config.App.InstallationID, config.Org)
+}
+
+// parseRSAPrivateKey decodes a PEM encoded PKCS#1 or PKCS#8 RSA private key, as downloaded from GitHub.
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return key, nil
+}
+
+// GitCredentials returns the HTTP basic auth credentials used to clone and push a provider's repositories.
+// GitHub App providers get a current installation token; every other provider uses its username and token.
+func GitCredentials(ctx context.Context, config domain.ProviderConfig) (username, password string, err error) {
+	if !strings.EqualFold(config.Provider, ProviderGithub) || !config.App.Configured() {
+		return config.Username, config.Token, nil
+	}
+	source, err := githubAppSourceFor(config)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := source.Token(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return githubAppGitUser, token, nil
+}
@@ -0,0 +1,165 @@
+package repository_providers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// writeTestAppKey writes a fresh RSA private key to a PEM file and returns its path.
+func writeTestAppKey(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "app.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	return path
+}
+
+// fakeGithubAppServer serves the app endpoints of a GitHub Enterprise instance and counts issued tokens.
+func fakeGithubAppServer(t *testing.T, appID int64, issued *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/orgs/tools/installation", func(w http.ResponseWriter, r *http.Request) {
+		checkAppJWT(t, r, appID)
+		_, _ = fmt.Fprint(w, `{"id": 42}`)
+	})
+	mux.HandleFunc("POST /api/v3/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		checkAppJWT(t, r, appID)
+		n := atomic.AddInt32(issued, 1)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"token": "inst-%d", "expires_at": %q}`, n, time.Now().Add(time.Hour).Format(time.RFC3339))
+	})
+	mux.HandleFunc("GET /api/v3/orgs/tools/repos", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token inst-1" {
+			t.Errorf("unexpected authorization header: %q", got)
+		}
+		_, _ = fmt.Fprint(w, `[{"name": "one", "clone_url": "https://ghe.example.com/tools/one.git"}]`)
+	})
+	return httptest.NewServer(mux)
+}
+
+// checkAppJWT verifies the request is authenticated with a JWT issued by appID.
+func checkAppJWT(t *testing.T, r *http.Request, appID int64) {
+	t.Helper()
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if !ok || len(parts) != 3 {
+		t.Errorf("expected a bearer JWT, got %q", r.Header.Get("Authorization"))
+		return
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Iss int64 `json:"iss"`
+		Iat int64 `json:"iat"`
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		t.Errorf("decoding JWT claims: %v", err)
+		return
+	}
+	if claims.Iss != appID || claims.Exp <= claims.Iat {
+		t.Errorf("unexpected JWT claims: %+v", claims)
+	}
+}
+
+func TestGithubApp_InstallationTokenUsedForAPIAndGit(t *testing.T) {
+	var issued int32
+	srv := fakeGithubAppServer(t, 7, &issued)
+	defer srv.Close()
+
+	config := domain.ProviderConfig{
+		Provider: ProviderGithub,
+		Org:      "tools",
+		BaseURL:  srv.URL + "/api/v3/",
+		App:      domain.GithubAppConfig{AppID: 7, PrivateKeyFile: writeTestAppKey(t)},
+	}
+	p, err := NewGithubRepositoryProvider(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repos, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if len(*repos) != 1 || (*repos)[0].Name != "one" {
+		t.Fatalf("unexpected repositories: %+v", *repos)
+	}
+
+	user, pass, err := GitCredentials(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GitCredentials unexpected error: %v", err)
+	}
+	if user != "x-access-token" || pass != "inst-1" {
+		t.Fatalf("unexpected git credentials: %s/%s", user, pass)
+	}
+	if issued != 1 {
+		t.Fatalf("expected the installation token to be reused, %d issued", issued)
+	}
+}
+
+func TestGithubAppTokenSource_RefreshesNearExpiry(t *testing.T) {
+	var issued int32
+	srv := fakeGithubAppServer(t, 7, &issued)
+	defer srv.Close()
+
+	source, err := newGithubAppTokenSource(domain.ProviderConfig{
+		Provider: ProviderGithub,
+		Org:      "tools",
+		BaseURL:  srv.URL + "/api/v3/",
+		App:      domain.GithubAppConfig{AppID: 7, InstallationID: 42, PrivateKeyFile: writeTestAppKey(t)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tok, _ := source.Token(context.Background()); tok != "inst-1" {
+		t.Fatalf("unexpected first token: %q", tok)
+	}
+	if tok, _ := source.Token(context.Background()); tok != "inst-1" {
+		t.Fatalf("expected cached token, got %q", tok)
+	}
+
+	source.now = func() time.Time { return time.Now().Add(56 * time.Minute) }
+	if tok, _ := source.Token(context.Background()); tok != "inst-2" {
+		t.Fatalf("expected a refreshed token, got %q", tok)
+	}
+}
+
+func TestGitCredentials_TokenProviders(t *testing.T) {
+	user, pass, err := GitCredentials(context.Background(), domain.ProviderConfig{Provider: ProviderGitlab, Username: "bot", Token: "secret"})
+	if err != nil || user != "bot" || pass != "secret" {
+		t.Fatalf("unexpected credentials: %s/%s err=%v", user, pass, err)
+	}
+}
+
+func TestParseRSAPrivateKey_PKCS8(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	parsed, err := parseRSAPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil || !parsed.Equal(key) {
+		t.Fatalf("expected the PKCS#8 key to parse, err=%v", err)
+	}
+	if _, err := parseRSAPrivateKey([]byte("not a key")); err == nil {
+		t.Fatalf("expected an error for non-PEM data")
+	}
+}
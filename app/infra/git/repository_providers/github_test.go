@@ -1,11 +1,16 @@
 package repository_providers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	github "github.com/google/go-github/v72/github"
 
@@ -42,7 +47,7 @@ func TestGithubProvider_GetRepositories_Success(t *testing.T) {
 	provider := &GithubRepositoryProvider{github: client, orgName: org}
 
 	// Act
-	got, err := provider.GetRepositories()
+	got, err := provider.GetRepositories(context.Background())
 	if err != nil {
 		t.Fatalf("GetRepositories unexpected error: %v", err)
 	}
@@ -57,10 +62,233 @@ func TestGithubProvider_GetRepositories_Success(t *testing.T) {
 	}
 }
 
+func TestGithubProvider_GetRepositories_Paginates(t *testing.T) {
+	org := "my-org"
+	var pageSizes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageSizes = append(pageSizes, r.URL.Query().Get("per_page"))
+		page := r.URL.Query().Get("page")
+		w.Header().Set("Content-Type", "application/json")
+		if page == "1" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/%s/repos?page=2&per_page=2>; rel="next"`, "http://"+r.Host, org))
+			_, _ = w.Write([]byte(`[{"name": "a", "clone_url": "https://github.com/my-org/a.git"}, {"name": "b", "clone_url": "https://github.com/my-org/b.git"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"name": "c", "clone_url": "https://github.com/my-org/c.git"}]`))
+	}))
+	defer srv.Close()
+
+	client := github.NewClient(srv.Client())
+	base, _ := url.Parse(srv.URL + "/")
+	client.BaseURL = base
+
+	provider := &GithubRepositoryProvider{github: client, orgName: org, pageSize: 2}
+	got, err := provider.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if len(*got) != 3 || (*got)[2].Name != "c" {
+		t.Fatalf("expected repositories from both pages, got %#v", *got)
+	}
+	if !reflect.DeepEqual(pageSizes, []string{"2", "2"}) {
+		t.Fatalf("expected configured page size on every request, got %v", pageSizes)
+	}
+}
+
+func TestGithubProvider_GetRepositories_Metadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"id": 7, "name": "api", "clone_url": "https://github.com/acme/api.git", "owner": {"login": "acme"},
+			"default_branch": "main", "archived": true, "fork": true, "private": true, "topics": ["go", "service"]}]`))
+	}))
+	defer srv.Close()
+
+	client := github.NewClient(srv.Client())
+	base, _ := url.Parse(srv.URL + "/")
+	client.BaseURL = base
+
+	provider := &GithubRepositoryProvider{github: client, orgName: "acme"}
+	got, err := provider.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	want := []domain.GitRepository{{
+		Name:          "api",
+		Url:           "https://github.com/acme/api.git",
+		Owner:         "acme",
+		ID:            "7",
+		DefaultBranch: "main",
+		Archived:      true,
+		Fork:          true,
+		Visibility:    domain.VisibilityPrivate,
+		Topics:        []string{"go", "service"},
+	}}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
+	}
+}
+
+func TestGithubProvider_GetRepositories_Filter(t *testing.T) {
+	var listType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listType = r.URL.Query().Get("type")
+		_, _ = w.Write([]byte(`[{"name": "api", "clone_url": "https://github.com/acme/api.git", "archived": true},
+			{"name": "sandbox-x", "clone_url": "https://github.com/acme/sandbox-x.git"},
+			{"name": "web", "clone_url": "https://github.com/acme/web.git"}]`))
+	}))
+	defer srv.Close()
+
+	p, err := NewGithubRepositoryProvider(domain.ProviderConfig{Org: "acme", Token: "t", BaseURL: srv.URL + "/api/v3/", Filter: domain.RepositoryFilter{
+		SkipArchived: true,
+		SkipForks:    true,
+		Exclude:      []string{"^sandbox-"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if len(*got) != 1 || (*got)[0].Name != "web" {
+		t.Fatalf("expected only web to be kept, got %#v", *got)
+	}
+	if listType != "sources" {
+		t.Fatalf("expected forks to be excluded server side, got type=%q", listType)
+	}
+}
+
+func TestGithubProvider_GetRepositories_UserNamespace(t *testing.T) {
+	cases := map[string]string{
+		"alice": "/api/v3/user/repos",
+		"bob":   "/api/v3/users/bob/repos",
+	}
+	for user, wantPath := range cases {
+		t.Run(user, func(t *testing.T) {
+			var listed string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/v3/user" {
+					_, _ = w.Write([]byte(`{"login": "Alice"}`))
+					return
+				}
+				listed = r.URL.Path
+				_, _ = w.Write([]byte(`[{"name": "dotfiles", "clone_url": "https://github.com/` + user + `/dotfiles.git"}]`))
+			}))
+			defer srv.Close()
+
+			p, err := NewGithubRepositoryProvider(domain.ProviderConfig{Users: []string{user}, Token: "t", BaseURL: srv.URL + "/api/v3/"}.Namespaces()[0])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := p.GetRepositories(context.Background())
+			if err != nil {
+				t.Fatalf("GetRepositories unexpected error: %v", err)
+			}
+			if len(*got) != 1 || listed != wantPath {
+				t.Fatalf("expected repositories from %s, got %#v from %s", wantPath, *got, listed)
+			}
+		})
+	}
+}
+
+func TestGithubProvider_ReadIdentifier(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/api/contents/.boneclone.yaml":
+			// "accepts: [skel]\n" base64 encoded, as the contents API returns files.
+			_, _ = w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "YWNjZXB0czogW3NrZWxdCg=="}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := github.NewClient(srv.Client())
+	base, _ := url.Parse(srv.URL + "/")
+	client.BaseURL = base
+	provider := &GithubRepositoryProvider{github: client, orgName: "acme"}
+
+	content, err := provider.ReadIdentifier(context.Background(), domain.GitRepository{Name: "api"}, ".boneclone.yaml")
+	if err != nil || string(content) != "accepts: [skel]\n" {
+		t.Fatalf("unexpected identifier %q, err=%v", content, err)
+	}
+	if _, err := provider.ReadIdentifier(context.Background(), domain.GitRepository{Name: "web"}, ".boneclone.yaml"); !errors.Is(err, domain.ErrIdentifierNotFound) {
+		t.Fatalf("expected ErrIdentifierNotFound, got %v", err)
+	}
+}
+
+func TestGithubProvider_SearchRepositories(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/code":
+			query = r.URL.Query().Get("q")
+			_, _ = w.Write([]byte(`{"total_count": 2, "incomplete_results": false, "items": [
+				{"path": ".github/boneclone.yaml", "repository": {"name": "api", "full_name": "acme/api", "owner": {"login": "acme"}}},
+				{"path": "docs/.github/boneclone.yaml", "repository": {"name": "api", "full_name": "acme/api", "owner": {"login": "acme"}}}]}`))
+		case "/repos/acme/api":
+			_, _ = w.Write([]byte(`{"id": 7, "name": "api", "clone_url": "https://github.com/acme/api.git", "owner": {"login": "acme"}, "default_branch": "main"}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client := github.NewClient(srv.Client())
+	base, _ := url.Parse(srv.URL + "/")
+	client.BaseURL = base
+	filter, _ := domain.NewRepositoryMatcher(domain.RepositoryFilter{SkipForks: true})
+	provider := &GithubRepositoryProvider{github: client, orgName: "acme", filter: filter}
+
+	got, err := provider.SearchRepositories(context.Background(), ".github/boneclone.yaml")
+	if err != nil {
+		t.Fatalf("SearchRepositories unexpected error: %v", err)
+	}
+	if query != "filename:boneclone.yaml org:acme path:.github" {
+		t.Fatalf("unexpected search query: %q", query)
+	}
+	if len(*got) != 1 || (*got)[0].Url != "https://github.com/acme/api.git" || (*got)[0].DefaultBranch != "main" {
+		t.Fatalf("expected api once with its full metadata, got %#v", *got)
+	}
+}
+
+func TestGithubProvider_SearchRepositories_Unusable(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		filter domain.RepositoryFilter
+	}{
+		{name: "incomplete", body: `{"total_count": 1, "incomplete_results": true, "items": []}`, filter: domain.RepositoryFilter{SkipForks: true}},
+		{name: "truncated at 1000 results", body: `{"total_count": 1500, "incomplete_results": false, "items": [
+			{"path": ".boneclone.yaml", "repository": {"name": "api", "full_name": "acme/api", "owner": {"login": "acme"}}}]}`, filter: domain.RepositoryFilter{SkipForks: true}},
+		{name: "forks kept", body: `{"total_count": 0, "incomplete_results": false, "items": []}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			client := github.NewClient(srv.Client())
+			base, _ := url.Parse(srv.URL + "/")
+			client.BaseURL = base
+			filter, _ := domain.NewRepositoryMatcher(tc.filter)
+			provider := &GithubRepositoryProvider{github: client, orgName: "acme", filter: filter}
+
+			if _, err := provider.SearchRepositories(context.Background(), ".boneclone.yaml"); err == nil {
+				t.Fatalf("expected an error so discovery falls back to listing")
+			}
+		})
+	}
+}
+
 func TestGithubProvider_GetRepositories_APIError(t *testing.T) {
 	org := "acme"
+	var calls atomic.Int32
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/orgs/"+org+"/repos" {
+			calls.Add(1)
 			http.Error(w, `{"message":"boom"}`, http.StatusInternalServerError)
 			return
 		}
@@ -75,14 +303,45 @@ func TestGithubProvider_GetRepositories_APIError(t *testing.T) {
 
 	provider := &GithubRepositoryProvider{github: client, orgName: org}
 
-	repos, err := provider.GetRepositories()
+	ctx := domain.WithRetryConfig(context.Background(), domain.RetryConfig{Attempts: 3, InitialDelay: time.Millisecond})
+	repos, err := provider.GetRepositories(ctx)
 	if err == nil {
 		t.Fatalf("expected error, got nil and repos=%v", repos)
 	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected a 500 to be retried up to 3 attempts, got %d calls", got)
+	}
+}
+
+func TestNewGithubRepositoryProvider_Enterprise(t *testing.T) {
+	org := "my-org"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/orgs/"+org+"/repos" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("unexpected authorization header: %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name": "repo-one", "clone_url": "https://github.example.com/my-org/repo-one.git"}]`))
+	}))
+	defer srv.Close()
+
+	p, err := NewGithubRepositoryProvider(domain.ProviderConfig{Token: "token", Org: org, BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if len(*got) != 1 || (*got)[0].Url != "https://github.example.com/my-org/repo-one.git" {
+		t.Fatalf("unexpected repositories: %#v", *got)
+	}
 }
 
 func TestNewGithubRepositoryProvider_Constructs(t *testing.T) {
-	p, err := NewGithubRepositoryProvider("token", "any-org")
+	p, err := NewGithubRepositoryProvider(domain.ProviderConfig{Token: "token", Org: "any-org"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -93,3 +352,13 @@ func TestNewGithubRepositoryProvider_Constructs(t *testing.T) {
 		t.Fatalf("provider does not implement domain.GitRepositoryProvider")
 	}
 }
+
+func TestNewGithubRepositoryProvider_SearchRequiresSkipForks(t *testing.T) {
+	if _, err := NewGithubRepositoryProvider(domain.ProviderConfig{Token: "token", Org: "any-org", Discovery: domain.DiscoverySearch}); err == nil {
+		t.Fatalf("expected error for search discovery without filter.skipForks")
+	}
+	config := domain.ProviderConfig{Token: "token", Org: "any-org", Discovery: domain.DiscoverySearch, Filter: domain.RepositoryFilter{SkipForks: true}}
+	if _, err := NewGithubRepositoryProvider(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
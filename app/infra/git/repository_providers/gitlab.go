@@ -3,18 +3,44 @@ package repository_providers
 import (
 	"context"
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 
 	"go.iain.rocks/boneclone/app/domain"
 )
 
+// gitlabSearchPageSize is the largest page the search API returns.
+const gitlabSearchPageSize = 100
+
 // Small interface to allow testing without real GitLab client
 // It matches the single method we use from the Groups service.
 type gitlabGroupProjectLister interface {
 	ListGroupProjects(gid interface{}, opt *gitlab.ListGroupProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
 }
 
+// gitlabUserProjectLister lists the projects in a user's personal namespace.
+type gitlabUserProjectLister interface {
+	ListUserProjects(uid interface{}, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
+}
+
+// gitlabBlobSearcher searches file contents and names within a group.
+type gitlabBlobSearcher interface {
+	BlobsByGroup(gid interface{}, query string, opt *gitlab.SearchOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Blob, *gitlab.Response, error)
+}
+
+// gitlabProjectGetter loads a single project.
+type gitlabProjectGetter interface {
+	GetProject(pid interface{}, opt *gitlab.GetProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error)
+}
+
+// gitlabFileReader reads files from a project's repository.
+type gitlabFileReader interface {
+	GetRawFile(pid interface{}, fileName string, opt *gitlab.GetRawFileOptions, options ...gitlab.RequestOptionFunc) ([]byte, *gitlab.Response, error)
+}
+
 // Small interfaces for merge requests and users to enable testing without real client.
 // Only the methods used are included.
 type gitlabMergeRequestService interface {
@@ -27,25 +53,45 @@ type gitlabUserLister interface {
 }
 
 type GitlabRepositoryProvider struct {
-	groups gitlabGroupProjectLister
-	mrs    gitlabMergeRequestService
-	users  gitlabUserLister
-	org    string
+	groups       gitlabGroupProjectLister
+	userProjects gitlabUserProjectLister
+	files        gitlabFileReader
+	search       gitlabBlobSearcher
+	projects     gitlabProjectGetter
+	mrs          gitlabMergeRequestService
+	users        gitlabUserLister
+	org          string
+	user         bool
+	pageSize     int
+	filter       *domain.RepositoryMatcher
 }
 
-func (g GitlabRepositoryProvider) GetRepositories() (*[]domain.GitRepository, error) {
-	trueValue := true
-	opts := &gitlab.ListGroupProjectsOptions{
-		IncludeSubGroups: &trueValue,
-		ListOptions: gitlab.ListOptions{
-			PerPage: 100,
-			Page:    1,
-		},
+// GetRepositories lists every project in the group and its subgroups, or in the user's personal namespace
+// for user namespaces, and drops those skipped by the provider's filter.
+func (g GitlabRepositoryProvider) GetRepositories(ctx context.Context) (*[]domain.GitRepository, error) {
+	var archived *bool
+	if g.filter.SkipsArchived() {
+		falseValue := false
+		archived = &falseValue
 	}
+	listOpts := gitlab.ListOptions{PerPage: pageSizeOrDefault(g.pageSize), Page: 1}
 
 	var allProjects []*gitlab.Project
 	for {
-		projects, resp, err := g.groups.ListGroupProjects(g.org, opts)
+		var resp *gitlab.Response
+		projects, err := callAPI(ctx, "list gitlab projects for "+g.org, func(ctx context.Context) ([]*gitlab.Project, error) {
+			if g.user {
+				opts := &gitlab.ListProjectsOptions{Archived: archived, ListOptions: listOpts}
+				projects, r, err := g.userProjects.ListUserProjects(g.org, opts, gitlab.WithContext(ctx))
+				resp = r
+				return projects, err
+			}
+			trueValue := true
+			opts := &gitlab.ListGroupProjectsOptions{IncludeSubGroups: &trueValue, Archived: archived, ListOptions: listOpts}
+			projects, r, err := g.groups.ListGroupProjects(g.org, opts, gitlab.WithContext(ctx))
+			resp = r
+			return projects, err
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -55,20 +101,42 @@ func (g GitlabRepositoryProvider) GetRepositories() (*[]domain.GitRepository, er
 		if resp.NextPage == 0 {
 			break // No more pages
 		}
-		opts.Page = resp.NextPage
+		listOpts.Page = resp.NextPage
 	}
 
 	output := make([]domain.GitRepository, 0, len(allProjects))
 	for _, project := range allProjects {
-		output = append(output, domain.GitRepository{
-			Name: project.Name,
-			Url:  project.HTTPURLToRepo,
-		})
+		output = append(output, gitlabRepository(project))
 	}
+	output = g.filter.Filter(output)
 
 	return &output, nil
 }
 
+// gitlabRepository converts a project listed by the GitLab API. It is named by its full path so projects in
+// subgroups, shared with the group or in user namespaces get merge requests in the right place.
+func gitlabRepository(project *gitlab.Project) domain.GitRepository {
+	repo := domain.GitRepository{
+		Name:          project.PathWithNamespace,
+		Url:           project.HTTPURLToRepo,
+		DefaultBranch: project.DefaultBranch,
+		Archived:      project.Archived,
+		Fork:          project.ForkedFromProject != nil,
+		Visibility:    string(project.Visibility),
+		Topics:        project.Topics,
+	}
+	if project.ID != 0 {
+		repo.ID = strconv.Itoa(project.ID)
+	}
+	if project.LastActivityAt != nil {
+		repo.LastActivity = *project.LastActivityAt
+	}
+	if project.Namespace != nil {
+		repo.Owner = project.Namespace.FullPath
+	}
+	return repo
+}
+
 // CreatePullRequest creates a merge request on GitLab for the given repo (within the configured org/group).
 // baseBranch is the target, headBranch is the source.
 // The merge request body is produced by the provided buildBody function.
@@ -85,8 +153,11 @@ func (g GitlabRepositoryProvider) CreatePullRequest(ctx context.Context, repo, b
 		Description:  &body,
 	}
 
-	pid := fmt.Sprintf("%s/%s", g.org, repo)
-	mr, _, err := g.mrs.CreateMergeRequest(pid, opt, gitlab.WithContext(ctx))
+	pid := g.projectPath(repo)
+	mr, err := callAPI(ctx, "create gitlab merge request for "+pid, func(ctx context.Context) (*gitlab.MergeRequest, error) {
+		mr, _, err := g.mrs.CreateMergeRequest(pid, opt, gitlab.WithContext(ctx))
+		return mr, err
+	})
 	if err != nil {
 		return domain.PRInfo{}, err
 	}
@@ -106,7 +177,10 @@ func (g GitlabRepositoryProvider) AssignReviewers(ctx context.Context, repo stri
 			continue
 		}
 		opt := &gitlab.ListUsersOptions{Username: &uname}
-		users, _, err := g.users.ListUsers(opt, gitlab.WithContext(ctx))
+		users, err := callAPI(ctx, "look up gitlab user "+uname, func(ctx context.Context) ([]*gitlab.User, error) {
+			users, _, err := g.users.ListUsers(opt, gitlab.WithContext(ctx))
+			return users, err
+		})
 		if err != nil {
 			return err
 		}
@@ -123,16 +197,131 @@ func (g GitlabRepositoryProvider) AssignReviewers(ctx context.Context, repo stri
 	if len(ids) == 0 {
 		return nil
 	}
-	pid := fmt.Sprintf("%s/%s", g.org, repo)
+	pid := g.projectPath(repo)
 	opt := &gitlab.UpdateMergeRequestOptions{ReviewerIDs: &ids}
-	_, _, err := g.mrs.UpdateMergeRequest(pid, pr.ID, opt, gitlab.WithContext(ctx))
+	_, err := callAPI(ctx, "assign gitlab reviewers for "+pid, func(ctx context.Context) (*gitlab.MergeRequest, error) {
+		mr, _, err := g.mrs.UpdateMergeRequest(pid, pr.ID, opt, gitlab.WithContext(ctx))
+		return mr, err
+	})
 	return err
 }
 
-func NewGitlabRepositoryProvider(token, org string) (domain.GitRepositoryProvider, error) {
-	client, err := gitlab.NewClient(token)
+// SearchRepositories finds the group's projects containing the identifier file through blob search, then loads
+// each one for its metadata and drops those skipped by the provider's filter. Filename search needs GitLab
+// advanced search; without it the search fails and discovery falls back to listing every project.
+func (g GitlabRepositoryProvider) SearchRepositories(ctx context.Context, identifier string) (*[]domain.GitRepository, error) {
+	if g.user {
+		return nil, fmt.Errorf("gitlab code search is not supported for user namespaces")
+	}
+	if g.search == nil || g.projects == nil {
+		return nil, fmt.Errorf("gitlab search is not available")
+	}
+	query := "filename:" + path.Base(identifier)
+	if dir := path.Dir(identifier); dir != "." {
+		query += " path:" + dir
+	}
+
+	opts := &gitlab.SearchOptions{ListOptions: gitlab.ListOptions{PerPage: gitlabSearchPageSize, Page: 1}}
+	var projectIDs []int
+	seen := map[int]struct{}{}
+	for {
+		var resp *gitlab.Response
+		blobs, err := callAPI(ctx, "search gitlab blobs for "+g.org, func(ctx context.Context) ([]*gitlab.Blob, error) {
+			blobs, r, err := g.search.BlobsByGroup(g.org, query, opts, gitlab.WithContext(ctx))
+			resp = r
+			return blobs, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, blob := range blobs {
+			if _, ok := seen[blob.ProjectID]; ok {
+				continue
+			}
+			seen[blob.ProjectID] = struct{}{}
+			projectIDs = append(projectIDs, blob.ProjectID)
+		}
+
+		if resp.NextPage == 0 {
+			break // No more pages
+		}
+		opts.Page = resp.NextPage
+	}
+
+	output := make([]domain.GitRepository, 0, len(projectIDs))
+	for _, id := range projectIDs {
+		project, err := callAPI(ctx, fmt.Sprintf("get gitlab project %d", id), func(ctx context.Context) (*gitlab.Project, error) {
+			project, _, err := g.projects.GetProject(id, nil, gitlab.WithContext(ctx))
+			return project, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, gitlabRepository(project))
+	}
+	output = g.filter.Filter(output)
+
+	return &output, nil
+}
+
+// ReadIdentifier reads path from the project's default branch through the repository files API.
+func (g GitlabRepositoryProvider) ReadIdentifier(ctx context.Context, repo domain.GitRepository, path string) ([]byte, error) {
+	if g.files == nil {
+		return nil, fmt.Errorf("gitlab repository files are not available")
+	}
+	opt := &gitlab.GetRawFileOptions{}
+	if repo.DefaultBranch != "" {
+		opt.Ref = &repo.DefaultBranch
+	}
+	pid := g.projectPath(repo.Name)
+	content, err := callAPI(ctx, "read gitlab "+path+" for "+pid, func(ctx context.Context) ([]byte, error) {
+		content, _, err := g.files.GetRawFile(pid, path, opt, gitlab.WithContext(ctx))
+		return content, err
+	})
+	if isNotFound(err) {
+		return nil, domain.ErrIdentifierNotFound
+	}
+	return content, err
+}
+
+// projectPath returns the project path used by the merge request API. Discovered repositories are named by
+// their full path (group/subgroup/project); a bare project name is taken to be directly in the configured group.
+func (g GitlabRepositoryProvider) projectPath(repo string) string {
+	if strings.Contains(repo, "/") {
+		return repo
+	}
+	return g.org + "/" + repo
+}
+
+// NewGitlabRepositoryProvider creates a provider for gitlab.com, or for a self-managed GitLab
+// instance when config.BaseURL is set.
+func NewGitlabRepositoryProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+	options := []gitlab.ClientOptionFunc{
+		gitlab.WithHTTPClient(newRateLimitedClient(budgetFor(ProviderGitlab, config.BaseURL, config.Token))),
+	}
+	if config.BaseURL != "" {
+		options = append(options, gitlab.WithBaseURL(config.BaseURL))
+	}
+	client, err := gitlab.NewClient(config.Token, options...)
 	if err != nil {
 		return nil, err
 	}
-	return &GitlabRepositoryProvider{groups: client.Groups, mrs: client.MergeRequests, users: client.Users, org: org}, nil
+	filter, err := domain.NewRepositoryMatcher(config.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gitlab filter: %w", err)
+	}
+	return &GitlabRepositoryProvider{
+		groups:       client.Groups,
+		userProjects: client.Projects,
+		files:        client.RepositoryFiles,
+		search:       client.Search,
+		projects:     client.Projects,
+		mrs:          client.MergeRequests,
+		users:        client.Users,
+		org:          config.Org,
+		user:         config.UserNamespace(),
+		pageSize:     config.PageSize,
+		filter:       filter,
+	}, nil
 }
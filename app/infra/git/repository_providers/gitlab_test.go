@@ -1,7 +1,12 @@
 package repository_providers
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
 
@@ -17,11 +22,14 @@ type fakeGroupsService struct {
 	// errs is a slice of errors to return on successive calls (nil for no error)
 	errs []error
 	call int
+	// perPage records the page size requested on each call
+	perPage []int
 }
 
 func (f *fakeGroupsService) ListGroupProjects(gid interface{}, opt *gitlab.ListGroupProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
 	i := f.call
 	f.call++
+	f.perPage = append(f.perPage, opt.PerPage)
 	var err error
 	if i < len(f.errs) {
 		err = f.errs[i]
@@ -43,9 +51,9 @@ func (f *fakeGroupsService) ListGroupProjects(gid interface{}, opt *gitlab.ListG
 
 func TestGitlabProvider_GetRepositories_PaginationSuccess(t *testing.T) {
 	// Arrange: two pages of projects
-	p1 := &gitlab.Project{Name: "RepoA", HTTPURLToRepo: "https://gitlab.com/org/repoA.git"}
-	p2 := &gitlab.Project{Name: "RepoB", HTTPURLToRepo: "https://gitlab.com/org/repoB.git"}
-	p3 := &gitlab.Project{Name: "RepoC", HTTPURLToRepo: "https://gitlab.com/org/repoC.git"}
+	p1 := &gitlab.Project{Name: "RepoA", PathWithNamespace: "org/repoA", HTTPURLToRepo: "https://gitlab.com/org/repoA.git"}
+	p2 := &gitlab.Project{Name: "RepoB", PathWithNamespace: "org/repoB", HTTPURLToRepo: "https://gitlab.com/org/repoB.git"}
+	p3 := &gitlab.Project{Name: "RepoC", PathWithNamespace: "org/sub/repoC", HTTPURLToRepo: "https://gitlab.com/org/sub/repoC.git"}
 
 	fake := &fakeGroupsService{
 		pages: [][]*gitlab.Project{{p1, p2}, {p3}},
@@ -55,22 +63,42 @@ func TestGitlabProvider_GetRepositories_PaginationSuccess(t *testing.T) {
 	provider := &GitlabRepositoryProvider{groups: fake, org: "my-org"}
 
 	// Act
-	got, err := provider.GetRepositories()
+	got, err := provider.GetRepositories(context.Background())
 	if err != nil {
 		t.Fatalf("GetRepositories unexpected error: %v", err)
 	}
 
 	// Assert
 	want := []domain.GitRepository{
-		{Name: "RepoA", Url: "https://gitlab.com/org/repoA.git"},
-		{Name: "RepoB", Url: "https://gitlab.com/org/repoB.git"},
-		{Name: "RepoC", Url: "https://gitlab.com/org/repoC.git"},
+		{Name: "org/repoA", Url: "https://gitlab.com/org/repoA.git"},
+		{Name: "org/repoB", Url: "https://gitlab.com/org/repoB.git"},
+		{Name: "org/sub/repoC", Url: "https://gitlab.com/org/sub/repoC.git"},
 	}
 	if !reflect.DeepEqual(*got, want) {
 		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
 	}
 }
 
+func TestGitlabProvider_GetRepositories_UsesConfiguredPageSize(t *testing.T) {
+	fake := &fakeGroupsService{pages: [][]*gitlab.Project{{}, {}}}
+	provider := &GitlabRepositoryProvider{groups: fake, org: "org", pageSize: 20}
+	if _, err := provider.GetRepositories(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(fake.perPage, []int{20, 20}) {
+		t.Fatalf("expected configured page size on every request, got %v", fake.perPage)
+	}
+
+	fake = &fakeGroupsService{pages: [][]*gitlab.Project{{}}}
+	provider = &GitlabRepositoryProvider{groups: fake, org: "org"}
+	if _, err := provider.GetRepositories(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(fake.perPage, []int{DefaultPageSize}) {
+		t.Fatalf("expected default page size, got %v", fake.perPage)
+	}
+}
+
 func TestGitlabProvider_GetRepositories_ErrorPropagation(t *testing.T) {
 	// First call errors, ensure it propagates
 	fake := &fakeGroupsService{
@@ -78,7 +106,7 @@ func TestGitlabProvider_GetRepositories_ErrorPropagation(t *testing.T) {
 		errs:  []error{errors.New("kaboom")},
 	}
 	provider := &GitlabRepositoryProvider{groups: fake, org: "org"}
-	repos, err := provider.GetRepositories()
+	repos, err := provider.GetRepositories(context.Background())
 	if err == nil {
 		t.Fatalf("expected error, got nil and repos=%v", repos)
 	}
@@ -87,7 +115,7 @@ func TestGitlabProvider_GetRepositories_ErrorPropagation(t *testing.T) {
 func TestGitlabProvider_GetRepositories_Empty(t *testing.T) {
 	fake := &fakeGroupsService{pages: [][]*gitlab.Project{{}}, errs: []error{nil}}
 	provider := &GitlabRepositoryProvider{groups: fake, org: "org"}
-	got, err := provider.GetRepositories()
+	got, err := provider.GetRepositories(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -100,7 +128,7 @@ func TestGitlabProvider_GetRepositories_Empty(t *testing.T) {
 }
 
 func TestNewGitlabRepositoryProvider_Constructs(t *testing.T) {
-	p, err := NewGitlabRepositoryProvider("token", "my-org")
+	p, err := NewGitlabRepositoryProvider(domain.ProviderConfig{Token: "token", Org: "my-org"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -111,3 +139,192 @@ func TestNewGitlabRepositoryProvider_Constructs(t *testing.T) {
 		t.Fatalf("provider does not implement domain.GitRepositoryProvider")
 	}
 }
+
+func TestNewGitlabRepositoryProvider_SelfManaged(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/groups/my-org/projects" {
+			t.Errorf("unexpected path: %s", r.URL.EscapedPath())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name": "repo-one", "http_url_to_repo": "https://gitlab.example.com/my-org/repo-one.git"}]`))
+	}))
+	defer srv.Close()
+
+	p, err := NewGitlabRepositoryProvider(domain.ProviderConfig{Token: "token", Org: "my-org", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if len(*got) != 1 || (*got)[0].Url != "https://gitlab.example.com/my-org/repo-one.git" {
+		t.Fatalf("unexpected repositories: %#v", *got)
+	}
+}
+
+func TestGitlabRepository_Metadata(t *testing.T) {
+	got := gitlabRepository(&gitlab.Project{
+		ID:                42,
+		Name:              "api",
+		PathWithNamespace: "group/sub/api",
+		HTTPURLToRepo:     "https://gitlab.com/group/sub/api.git",
+		DefaultBranch:     "main",
+		Archived:          true,
+		Visibility:        gitlab.InternalVisibility,
+		Topics:            []string{"go"},
+		Namespace:         &gitlab.ProjectNamespace{FullPath: "group/sub"},
+		ForkedFromProject: &gitlab.ForkParent{ID: 1},
+	})
+	want := domain.GitRepository{
+		Name:          "group/sub/api",
+		Url:           "https://gitlab.com/group/sub/api.git",
+		Owner:         "group/sub",
+		ID:            "42",
+		DefaultBranch: "main",
+		Archived:      true,
+		Fork:          true,
+		Visibility:    domain.VisibilityInternal,
+		Topics:        []string{"go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("gitlabRepository mismatch\nGot:  %#v\nWant: %#v", got, want)
+	}
+}
+
+// fakeUserProjects implements gitlabUserProjectLister, recording the user asked for.
+type fakeUserProjects struct {
+	uid interface{}
+}
+
+func (f *fakeUserProjects) ListUserProjects(uid interface{}, _ *gitlab.ListProjectsOptions, _ ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+	f.uid = uid
+	return []*gitlab.Project{{Name: "dotfiles", PathWithNamespace: "alice/dotfiles", HTTPURLToRepo: "https://gitlab.com/alice/dotfiles.git"}}, &gitlab.Response{}, nil
+}
+
+func TestGitlabProvider_GetRepositories_UserNamespace(t *testing.T) {
+	users := &fakeUserProjects{}
+	provider := &GitlabRepositoryProvider{groups: &fakeGroupsService{errs: []error{errors.New("groups must not be listed")}}, userProjects: users, org: "alice", user: true}
+
+	got, err := provider.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	if users.uid != "alice" || len(*got) != 1 || (*got)[0].Name != "alice/dotfiles" {
+		t.Fatalf("unexpected user listing for %v: %#v", users.uid, *got)
+	}
+}
+
+// fakeMergeRequests implements gitlabMergeRequestService, recording the project IDs used.
+type fakeMergeRequests struct {
+	pids []interface{}
+}
+
+func (f *fakeMergeRequests) CreateMergeRequest(pid interface{}, _ *gitlab.CreateMergeRequestOptions, _ ...gitlab.RequestOptionFunc) (*gitlab.MergeRequest, *gitlab.Response, error) {
+	f.pids = append(f.pids, pid)
+	return &gitlab.MergeRequest{BasicMergeRequest: gitlab.BasicMergeRequest{IID: 3}}, &gitlab.Response{}, nil
+}
+
+func (f *fakeMergeRequests) UpdateMergeRequest(pid interface{}, _ int, _ *gitlab.UpdateMergeRequestOptions, _ ...gitlab.RequestOptionFunc) (*gitlab.MergeRequest, *gitlab.Response, error) {
+	f.pids = append(f.pids, pid)
+	return &gitlab.MergeRequest{}, &gitlab.Response{}, nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) ListUsers(opt *gitlab.ListUsersOptions, _ ...gitlab.RequestOptionFunc) ([]*gitlab.User, *gitlab.Response, error) {
+	return []*gitlab.User{{ID: 1, Username: *opt.Username}}, &gitlab.Response{}, nil
+}
+
+func TestGitlabProvider_MergeRequestsUseProjectPath(t *testing.T) {
+	mrs := &fakeMergeRequests{}
+	provider := &GitlabRepositoryProvider{mrs: mrs, users: fakeUsers{}, org: "group"}
+
+	pr, err := provider.CreatePullRequest(context.Background(), "group/sub/api", "main", "boneclone/update", "Update", nil, "", nil)
+	if err != nil {
+		t.Fatalf("CreatePullRequest unexpected error: %v", err)
+	}
+	if err := provider.AssignReviewers(context.Background(), "group/sub/api", pr, []string{"alice"}); err != nil {
+		t.Fatalf("AssignReviewers unexpected error: %v", err)
+	}
+	if _, err := provider.CreatePullRequest(context.Background(), "tool", "main", "boneclone/update", "Update", nil, "", nil); err != nil {
+		t.Fatalf("CreatePullRequest unexpected error: %v", err)
+	}
+
+	want := []interface{}{"group/sub/api", "group/sub/api", "group/tool"}
+	if !reflect.DeepEqual(mrs.pids, want) {
+		t.Fatalf("expected project paths %v, got %v", want, mrs.pids)
+	}
+}
+
+// fakeFiles implements gitlabFileReader over a map of "pid:path" to content.
+type fakeFiles struct {
+	files map[string]string
+	refs  []string
+}
+
+func (f *fakeFiles) GetRawFile(pid interface{}, fileName string, opt *gitlab.GetRawFileOptions, _ ...gitlab.RequestOptionFunc) ([]byte, *gitlab.Response, error) {
+	if opt.Ref != nil {
+		f.refs = append(f.refs, *opt.Ref)
+	}
+	content, ok := f.files[fmt.Sprintf("%v:%s", pid, fileName)]
+	if !ok {
+		resp := &http.Response{StatusCode: http.StatusNotFound, Request: &http.Request{Method: http.MethodGet, URL: &url.URL{}}}
+		return nil, &gitlab.Response{Response: resp}, &gitlab.ErrorResponse{Response: resp}
+	}
+	return []byte(content), &gitlab.Response{}, nil
+}
+
+func TestGitlabProvider_ReadIdentifier(t *testing.T) {
+	files := &fakeFiles{files: map[string]string{"group/sub/api:.boneclone.yaml": "accepts: [skel]\n"}}
+	provider := &GitlabRepositoryProvider{files: files, org: "group"}
+
+	content, err := provider.ReadIdentifier(context.Background(), domain.GitRepository{Name: "group/sub/api", DefaultBranch: "develop"}, ".boneclone.yaml")
+	if err != nil || string(content) != "accepts: [skel]\n" {
+		t.Fatalf("unexpected identifier %q, err=%v", content, err)
+	}
+	if !reflect.DeepEqual(files.refs, []string{"develop"}) {
+		t.Fatalf("expected the default branch to be read, got %v", files.refs)
+	}
+
+	if _, err := provider.ReadIdentifier(context.Background(), domain.GitRepository{Name: "group/web"}, ".boneclone.yaml"); !errors.Is(err, domain.ErrIdentifierNotFound) {
+		t.Fatalf("expected ErrIdentifierNotFound, got %v", err)
+	}
+}
+
+// fakeSearch returns blobs for a group search and projects by ID.
+type fakeSearch struct {
+	query string
+}
+
+func (f *fakeSearch) BlobsByGroup(_ interface{}, query string, _ *gitlab.SearchOptions, _ ...gitlab.RequestOptionFunc) ([]*gitlab.Blob, *gitlab.Response, error) {
+	f.query = query
+	return []*gitlab.Blob{{ProjectID: 4}, {ProjectID: 9}, {ProjectID: 4}}, &gitlab.Response{}, nil
+}
+
+func (f *fakeSearch) GetProject(pid interface{}, _ *gitlab.GetProjectOptions, _ ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error) {
+	id := pid.(int)
+	return &gitlab.Project{ID: id, PathWithNamespace: fmt.Sprintf("group/p%d", id), Archived: id == 9}, &gitlab.Response{}, nil
+}
+
+func TestGitlabProvider_SearchRepositories(t *testing.T) {
+	search := &fakeSearch{}
+	filter, _ := domain.NewRepositoryMatcher(domain.RepositoryFilter{SkipArchived: true})
+	provider := &GitlabRepositoryProvider{search: search, projects: search, org: "group", filter: filter}
+
+	got, err := provider.SearchRepositories(context.Background(), ".boneclone.yaml")
+	if err != nil {
+		t.Fatalf("SearchRepositories unexpected error: %v", err)
+	}
+	if search.query != "filename:.boneclone.yaml" {
+		t.Fatalf("unexpected search query: %q", search.query)
+	}
+	if len(*got) != 1 || (*got)[0].Name != "group/p4" {
+		t.Fatalf("expected only the unarchived project once, got %#v", *got)
+	}
+
+	userProvider := &GitlabRepositoryProvider{search: search, projects: search, org: "alice", user: true}
+	if _, err := userProvider.SearchRepositories(context.Background(), ".boneclone.yaml"); err == nil {
+		t.Fatalf("expected user namespaces to be unsupported")
+	}
+}
@@ -0,0 +1,85 @@
+package repository_providers
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+const (
+	localURLScheme = "file://"
+	gitDirName     = ".git"
+)
+
+// LocalRepositoryProvider finds bare repositories in a directory tree. It has no pull request support,
+// so it is used with git.pullRequest: false and changes are pushed straight to the target branch.
+type LocalRepositoryProvider struct {
+	root string
+}
+
+// GetRepositories returns a file:// URL for every bare repository under the root directory.
+// Names are paths relative to the root without the .git suffix, e.g. team/service.
+func (l LocalRepositoryProvider) GetRepositories(ctx context.Context) (*[]domain.GitRepository, error) {
+	root, err := filepath.Abs(l.root)
+	if err != nil {
+		return nil, err
+	}
+
+	output := []domain.GitRepository{}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		// The .git directory of a working copy has the same layout as a bare repository.
+		if d.Name() == gitDirName {
+			return filepath.SkipDir
+		}
+		if !isBareRepository(path) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ".git")
+		if rel == "." {
+			name = strings.TrimSuffix(filepath.Base(path), ".git")
+		}
+		output = append(output, domain.GitRepository{Name: name, Url: localURLScheme + filepath.ToSlash(path)})
+		// A repository's own directories never contain further repositories.
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// isBareRepository reports whether dir looks like a bare git repository: a HEAD file next to objects and refs directories.
+func isBareRepository(dir string) bool {
+	if info, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil || info.IsDir() {
+		return false
+	}
+	for _, sub := range []string{"objects", "refs"} {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// NewLocalRepositoryProvider creates a provider for the bare repositories under config.Path.
+func NewLocalRepositoryProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+	return &LocalRepositoryProvider{root: config.Path}, nil
+}
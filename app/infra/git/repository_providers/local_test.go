@@ -0,0 +1,55 @@
+package repository_providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	git "github.com/go-git/go-git/v6"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+func TestLocalProvider_GetRepositories(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"alpha.git", "team/beta.git"} {
+		if _, err := git.PlainInit(filepath.Join(root, dir), true); err != nil {
+			t.Fatalf("init %s: %v", dir, err)
+		}
+	}
+	// A working copy and a plain directory are not bare repositories and are skipped.
+	if _, err := git.PlainInit(filepath.Join(root, "checkout"), false); err != nil {
+		t.Fatalf("init checkout: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	p, err := NewLocalRepositoryProvider(domain.ProviderConfig{Provider: ProviderLocal, Path: root})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	want := []domain.GitRepository{
+		{Name: "alpha", Url: "file://" + filepath.ToSlash(filepath.Join(root, "alpha.git"))},
+		{Name: "team/beta", Url: "file://" + filepath.ToSlash(filepath.Join(root, "team", "beta.git"))},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
+	}
+	if _, ok := p.(domain.PullRequestManager); ok {
+		t.Fatalf("local provider should not support pull requests")
+	}
+}
+
+func TestLocalProvider_MissingPath(t *testing.T) {
+	p, _ := NewLocalRepositoryProvider(domain.ProviderConfig{Provider: ProviderLocal, Path: filepath.Join(t.TempDir(), "missing")})
+	if _, err := p.GetRepositories(context.Background()); err == nil {
+		t.Fatalf("expected error for missing path")
+	}
+}
@@ -2,20 +2,294 @@ package repository_providers
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"slices"
 	"strings"
 
 	"go.iain.rocks/boneclone/app/domain"
 )
 
+// Provider names accepted in providers[].provider.
+const (
+	ProviderGithub          = "github"
+	ProviderGitlab          = "gitlab"
+	ProviderAzure           = "azure"
+	ProviderBitbucket       = "bitbucket"
+	ProviderBitbucketServer = "bitbucket-server"
+	ProviderGitea           = "gitea"
+	ProviderStatic          = "static"
+	ProviderLocal           = "local"
+)
+
+// DefaultPageSize is the number of repositories requested per page during discovery when providers[].pageSize is not set.
+// It is also the largest page size the GitHub API accepts.
+const DefaultPageSize = 100
+
+// SupportedProviders lists every provider name NewProvider understands.
+var SupportedProviders = []string{ProviderGithub, ProviderGitlab, ProviderAzure, ProviderBitbucket, ProviderBitbucketServer, ProviderGitea, ProviderStatic, ProviderLocal}
+
 func NewProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
 	switch strings.ToLower(config.Provider) {
-	case "github":
-		return NewGithubRepositoryProvider(config.Token, config.Org)
-	case "gitlab":
-		return NewGitlabRepositoryProvider(config.Token, config.Org)
-	case "azure":
-		return NewAzureRepositoryProvider(config.Token, config.Org)
+	case ProviderGithub:
+		return NewGithubRepositoryProvider(config)
+	case ProviderGitlab:
+		return NewGitlabRepositoryProvider(config)
+	case ProviderAzure:
+		return NewAzureRepositoryProvider(config)
+	case ProviderBitbucket:
+		return NewBitbucketRepositoryProvider(config)
+	case ProviderBitbucketServer:
+		return NewBitbucketServerRepositoryProvider(config)
+	case ProviderGitea:
+		return NewGiteaRepositoryProvider(config)
+	case ProviderStatic:
+		return NewStaticRepositoryProvider(config)
+	case ProviderLocal:
+		return NewLocalRepositoryProvider(config)
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", config.Provider)
 	}
 }
+
+// ValidateProviderConfig returns the problems with a single provider entry.
+// field is the entry's path in the config, e.g. providers[0].
+func ValidateProviderConfig(field string, config domain.ProviderConfig) []domain.ConfigProblem {
+	var problems []domain.ConfigProblem
+	add := func(name, message string) {
+		problems = append(problems, domain.ConfigProblem{Field: field + "." + name, Message: message})
+	}
+
+	name := strings.ToLower(strings.TrimSpace(config.Provider))
+	if name != "" && !config.Filter.IsZero() {
+		problems = append(problems, validateFilter(field, name, config.Filter)...)
+	}
+	if name != "" && config.ProbeIdentifier != nil && *config.ProbeIdentifier && !slices.Contains(probeProviders, name) {
+		add("probeIdentifier", fmt.Sprintf("is only supported by the %s providers", strings.Join(probeProviders, ", ")))
+	}
+	switch name {
+	case "":
+		add("provider", "is required")
+		return problems
+	case ProviderStatic:
+		return append(problems, validateStaticConfig(field, config)...)
+	case ProviderLocal:
+		return append(problems, validateLocalConfig(field, config)...)
+	case ProviderGithub, ProviderGitlab, ProviderAzure, ProviderBitbucket, ProviderBitbucketServer, ProviderGitea:
+	default:
+		add("provider", fmt.Sprintf("unknown provider %q, expected one of: %s", config.Provider, strings.Join(SupportedProviders, ", ")))
+		return problems
+	}
+	if len(config.Repositories) > 0 {
+		add("repositories", fmt.Sprintf("is only supported by the %s provider", ProviderStatic))
+	}
+
+	problems = append(problems, validateNamespaces(field, name, config)...)
+	problems = append(problems, validateAuth(field, name, config)...)
+	problems = append(problems, validateURLs(field, name, config)...)
+	problems = append(problems, validateDiscovery(field, name, config)...)
+	switch {
+	case config.PageSize != 0 && name == ProviderAzure:
+		add("pageSize", "is not supported by the azure provider")
+	case config.PageSize < 0 || config.PageSize > DefaultPageSize:
+		add("pageSize", fmt.Sprintf("must be between 1 and %d, got %d", DefaultPageSize, config.PageSize))
+	}
+	return problems
+}
+
+// validateNamespaces checks the org, orgs and users of a discovering provider entry.
+func validateNamespaces(field, name string, config domain.ProviderConfig) []domain.ConfigProblem {
+	var problems []domain.ConfigProblem
+	add := func(name, message string) {
+		problems = append(problems, domain.ConfigProblem{Field: field + "." + name, Message: message})
+	}
+
+	// Bitbucket Server lists every project when no project key is given.
+	if strings.TrimSpace(config.Org) == "" && len(config.Orgs) == 0 && len(config.Users) == 0 && name != ProviderBitbucketServer {
+		add("org", "is required (or set orgs or users)")
+	}
+	if name == ProviderAzure {
+		if config.Org != "" && !strings.HasPrefix(config.Org, "https://") {
+			add("org", fmt.Sprintf("must be the organization URL (e.g. https://dev.azure.com/example/), got %q", config.Org))
+		}
+		for i, org := range config.Orgs {
+			if !strings.HasPrefix(org, "https://") {
+				add(fmt.Sprintf("orgs[%d]", i), fmt.Sprintf("must be the organization URL (e.g. https://dev.azure.com/example/), got %q", org))
+			}
+		}
+	}
+	if len(config.Users) > 0 && !slices.Contains(userNamespaceProviders, name) {
+		add("users", fmt.Sprintf("is only supported by the %s providers", strings.Join(userNamespaceProviders, ", ")))
+	}
+	return problems
+}
+
+// validateAuth checks that a discovering provider entry has either a token or a complete GitHub App.
+func validateAuth(field, name string, config domain.ProviderConfig) []domain.ConfigProblem {
+	problem := func(name, message string) []domain.ConfigProblem {
+		return []domain.ConfigProblem{{Field: field + "." + name, Message: message}}
+	}
+
+	switch {
+	case config.App.Configured() && name != ProviderGithub:
+		return problem("app", "is only supported by the github provider")
+	case config.App.Configured() && strings.TrimSpace(config.App.PrivateKeyFile) == "":
+		return problem("app.privateKeyFile", "is required")
+	case config.App.Configured():
+		return nil
+	case config.App.InstallationID != 0 || config.App.PrivateKeyFile != "":
+		return problem("app.appId", "is required")
+	case strings.TrimSpace(config.Token) == "":
+		return problem("token", "is empty (after environment variable expansion)")
+	}
+	return nil
+}
+
+// validateURLs checks the baseUrl and uploadUrl of a discovering provider entry.
+func validateURLs(field, name string, config domain.ProviderConfig) []domain.ConfigProblem {
+	var problems []domain.ConfigProblem
+	add := func(name, message string) {
+		problems = append(problems, domain.ConfigProblem{Field: field + "." + name, Message: message})
+	}
+
+	switch {
+	case config.BaseURL == "" && (name == ProviderBitbucketServer || name == ProviderGitea):
+		add("baseUrl", fmt.Sprintf("is required for the %s provider", name))
+	case config.BaseURL != "" && name == ProviderAzure:
+		add("baseUrl", "is not supported by the azure provider, set org to the organization URL instead")
+	case config.BaseURL != "" && !isHTTPURL(config.BaseURL):
+		add("baseUrl", fmt.Sprintf("must be an http(s) URL, got %q", config.BaseURL))
+	}
+	switch {
+	case config.UploadURL == "":
+	case name != ProviderGithub:
+		add("uploadUrl", "is only supported by the github provider")
+	case config.BaseURL == "":
+		add("uploadUrl", "requires baseUrl")
+	case !isHTTPURL(config.UploadURL):
+		add("uploadUrl", fmt.Sprintf("must be an http(s) URL, got %q", config.UploadURL))
+	}
+	return problems
+}
+
+// validateDiscovery checks the discovery mode of a provider entry. GitHub code search never returns forks, so
+// searching GitHub without filter.skipForks would always fall back to listing.
+func validateDiscovery(field, name string, config domain.ProviderConfig) []domain.ConfigProblem {
+	switch strings.ToLower(config.Discovery) {
+	case "", domain.DiscoveryList:
+	case domain.DiscoverySearch:
+		if !slices.Contains(searchProviders, name) {
+			return []domain.ConfigProblem{{Field: field + ".discovery", Message: fmt.Sprintf("search is only supported by the %s providers", strings.Join(searchProviders, ", "))}}
+		}
+		if name == ProviderGithub && !config.Filter.SkipForks {
+			return []domain.ConfigProblem{{Field: field + ".discovery", Message: "search on github requires filter.skipForks, as code search never returns forks"}}
+		}
+	default:
+		return []domain.ConfigProblem{{Field: field + ".discovery", Message: fmt.Sprintf("must be %s or %s, got %q", domain.DiscoveryList, domain.DiscoverySearch, config.Discovery)}}
+	}
+	return nil
+}
+
+// pageSizeOrDefault returns size, or DefaultPageSize when size is not set.
+func pageSizeOrDefault(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	return size
+}
+
+// isHTTPURL reports whether value is an absolute http or https URL.
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// userNamespaceProviders are the provider types that can discover repositories in user namespaces.
+var userNamespaceProviders = []string{ProviderGithub, ProviderGitlab, ProviderGitea}
+
+// searchProviders are the provider types that can discover repositories through code search.
+var searchProviders = []string{ProviderGithub, ProviderGitlab}
+
+// probeProviders are the provider types that can read the identifier file through their API before cloning.
+var probeProviders = []string{ProviderGithub, ProviderGitlab, ProviderAzure}
+
+// filterProviders are the provider types that apply providers[].filter during discovery.
+var filterProviders = []string{ProviderGithub, ProviderGitlab, ProviderAzure}
+
+// topicProviders are the provider types that report repository topics for filter.topics.
+var topicProviders = []string{ProviderGithub, ProviderGitlab}
+
+// validateFilter checks a provider entry's discovery filter.
+func validateFilter(field, name string, filter domain.RepositoryFilter) []domain.ConfigProblem {
+	var problems []domain.ConfigProblem
+	add := func(message string) {
+		problems = append(problems, domain.ConfigProblem{Field: field + ".filter", Message: message})
+	}
+
+	if !slices.Contains(filterProviders, name) {
+		add(fmt.Sprintf("is only supported by the %s providers", strings.Join(filterProviders, ", ")))
+		return problems
+	}
+	if len(filter.Topics) > 0 && !slices.Contains(topicProviders, name) {
+		add(fmt.Sprintf("topics are only supported by the %s providers", strings.Join(topicProviders, ", ")))
+	}
+	if _, err := domain.NewRepositoryMatcher(filter); err != nil {
+		add(err.Error())
+	}
+	if filter.ActiveWithin < 0 {
+		add(fmt.Sprintf("activeWithin must not be negative, got %s", filter.ActiveWithin))
+	}
+	return problems
+}
+
+// validateStaticConfig checks a static provider entry. Org and token are optional because nothing is discovered;
+// the token is only needed for private clones and pull requests.
+func validateStaticConfig(field string, config domain.ProviderConfig) []domain.ConfigProblem {
+	var problems []domain.ConfigProblem
+	add := func(name, message string) {
+		problems = append(problems, domain.ConfigProblem{Field: field + "." + name, Message: message})
+	}
+
+	problems = append(problems, validateNoNamespaces(field, ProviderStatic, config)...)
+	if len(config.Repositories) == 0 {
+		add("repositories", "must list at least one repository")
+	}
+	for i, repo := range config.Repositories {
+		name := fmt.Sprintf("repositories[%d]", i)
+		if strings.TrimSpace(repo.Url) == "" {
+			add(name+".url", "is required")
+		}
+		if repo.PullRequestProvider != "" && !slices.Contains(staticPullRequestProviders, strings.ToLower(repo.PullRequestProvider)) {
+			add(name+".pullRequestProvider", fmt.Sprintf("unknown provider %q, expected one of: %s", repo.PullRequestProvider, strings.Join(staticPullRequestProviders, ", ")))
+		}
+	}
+	if config.BaseURL != "" && !isHTTPURL(config.BaseURL) {
+		add("baseUrl", fmt.Sprintf("must be an http(s) URL, got %q", config.BaseURL))
+	}
+	return problems
+}
+
+// validateLocalConfig checks a local provider entry. Local repositories are cloned and pushed over file://, so no
+// org or token is needed, but the path must be an existing directory.
+func validateLocalConfig(field string, config domain.ProviderConfig) []domain.ConfigProblem {
+	problems := validateNoNamespaces(field, ProviderLocal, config)
+	if strings.TrimSpace(config.Path) == "" {
+		return append(problems, domain.ConfigProblem{Field: field + ".path", Message: "is required"})
+	}
+	if info, err := os.Stat(config.Path); err != nil || !info.IsDir() {
+		problems = append(problems, domain.ConfigProblem{Field: field + ".path", Message: fmt.Sprintf("%q is not a directory", config.Path)})
+	}
+	return problems
+}
+
+// validateNoNamespaces reports orgs and users on a provider entry that does no discovery.
+func validateNoNamespaces(field, name string, config domain.ProviderConfig) []domain.ConfigProblem {
+	var problems []domain.ConfigProblem
+	if len(config.Orgs) > 0 {
+		problems = append(problems, domain.ConfigProblem{Field: field + ".orgs", Message: fmt.Sprintf("is not supported by the %s provider", name)})
+	}
+	if len(config.Users) > 0 {
+		problems = append(problems, domain.ConfigProblem{Field: field + ".users", Message: fmt.Sprintf("is not supported by the %s provider", name)})
+	}
+	return problems
+}
@@ -0,0 +1,76 @@
+package repository_providers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+func TestValidateProviderConfig(t *testing.T) {
+	gitRoot := t.TempDir()
+	cases := []struct {
+		name   string
+		config domain.ProviderConfig
+		want   []string
+	}{
+		{name: "valid", config: domain.ProviderConfig{Provider: "GitHub", Org: "o", Token: "t"}},
+		{name: "missing provider", config: domain.ProviderConfig{}, want: []string{"providers[0].provider"}},
+		{name: "unknown provider", config: domain.ProviderConfig{Provider: "githib", Org: "o", Token: "t"}, want: []string{"providers[0].provider"}},
+		{name: "missing org and token", config: domain.ProviderConfig{Provider: "gitlab"}, want: []string{"providers[0].org", "providers[0].token"}},
+		{name: "azure org not url", config: domain.ProviderConfig{Provider: "azure", Org: "example", Token: "t"}, want: []string{"providers[0].org"}},
+		{name: "github enterprise", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", BaseURL: "https://github.example.com/"}},
+		{name: "base url not a url", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", BaseURL: "github.example.com"}, want: []string{"providers[0].baseUrl"}},
+		{name: "upload url without base url", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", UploadURL: "https://github.example.com/"}, want: []string{"providers[0].uploadUrl"}},
+		{name: "self-managed gitlab", config: domain.ProviderConfig{Provider: "gitlab", Org: "o", Token: "t", BaseURL: "https://gitlab.example.com"}},
+		{name: "upload url on gitlab", config: domain.ProviderConfig{Provider: "gitlab", Org: "o", Token: "t", BaseURL: "https://gitlab.example.com", UploadURL: "https://gitlab.example.com"}, want: []string{"providers[0].uploadUrl"}},
+		{name: "bitbucket server all projects", config: domain.ProviderConfig{Provider: "bitbucket-server", Token: "t", BaseURL: "https://bitbucket.example.com"}},
+		{name: "bitbucket server without base url", config: domain.ProviderConfig{Provider: "bitbucket-server", Org: "PRJ", Token: "t"}, want: []string{"providers[0].baseUrl"}},
+		{name: "gitea without base url", config: domain.ProviderConfig{Provider: "gitea", Org: "o", Token: "t"}, want: []string{"providers[0].baseUrl"}},
+		{name: "static", config: domain.ProviderConfig{Provider: "static", Repositories: []domain.StaticRepository{{Url: "https://github.com/alice/tool.git", PullRequestProvider: "github"}}}},
+		{name: "static without repositories", config: domain.ProviderConfig{Provider: "static"}, want: []string{"providers[0].repositories"}},
+		{name: "static bad entries", config: domain.ProviderConfig{Provider: "static", Repositories: []domain.StaticRepository{{PullRequestProvider: "azure"}}}, want: []string{"providers[0].repositories[0].url", "providers[0].repositories[0].pullRequestProvider"}},
+		{name: "repositories on github", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", Repositories: []domain.StaticRepository{{Url: "https://github.com/o/r.git"}}}, want: []string{"providers[0].repositories"}},
+		{name: "local", config: domain.ProviderConfig{Provider: "local", Path: gitRoot}},
+		{name: "local without path", config: domain.ProviderConfig{Provider: "local"}, want: []string{"providers[0].path"}},
+		{name: "local path missing", config: domain.ProviderConfig{Provider: "local", Path: filepath.Join(gitRoot, "missing")}, want: []string{"providers[0].path"}},
+		{name: "github app", config: domain.ProviderConfig{Provider: "github", Org: "o", App: domain.GithubAppConfig{AppID: 1, PrivateKeyFile: "key.pem"}}},
+		{name: "github app without key", config: domain.ProviderConfig{Provider: "github", Org: "o", App: domain.GithubAppConfig{AppID: 1}}, want: []string{"providers[0].app.privateKeyFile"}},
+		{name: "github app without app id", config: domain.ProviderConfig{Provider: "github", Org: "o", App: domain.GithubAppConfig{PrivateKeyFile: "key.pem"}}, want: []string{"providers[0].app.appId"}},
+		{name: "app on gitlab", config: domain.ProviderConfig{Provider: "gitlab", Org: "o", App: domain.GithubAppConfig{AppID: 1, PrivateKeyFile: "key.pem"}}, want: []string{"providers[0].app"}},
+		{name: "github filter", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", Filter: domain.RepositoryFilter{SkipForks: true, Visibility: []string{"private"}, Exclude: []string{"^sandbox-"}}}},
+		{name: "filter bad pattern", config: domain.ProviderConfig{Provider: "gitlab", Org: "o", Token: "t", Filter: domain.RepositoryFilter{Include: []string{"("}}}, want: []string{"providers[0].filter"}},
+		{name: "filter bad visibility", config: domain.ProviderConfig{Provider: "azure", Org: "https://dev.azure.com/o/", Token: "t", Filter: domain.RepositoryFilter{Visibility: []string{"secret"}}}, want: []string{"providers[0].filter"}},
+		{name: "filter on gitea", config: domain.ProviderConfig{Provider: "gitea", Org: "o", Token: "t", BaseURL: "https://git.example.com", Filter: domain.RepositoryFilter{SkipArchived: true}}, want: []string{"providers[0].filter"}},
+		{name: "orgs and users", config: domain.ProviderConfig{Provider: "github", Orgs: []string{"a", "b"}, Users: []string{"alice"}, Token: "t"}},
+		{name: "azure orgs not urls", config: domain.ProviderConfig{Provider: "azure", Orgs: []string{"https://dev.azure.com/a/", "b"}, Token: "t"}, want: []string{"providers[0].orgs[1]"}},
+		{name: "users on bitbucket", config: domain.ProviderConfig{Provider: "bitbucket", Org: "ws", Users: []string{"alice"}, Token: "t"}, want: []string{"providers[0].users"}},
+		{name: "orgs on local", config: domain.ProviderConfig{Provider: "local", Path: gitRoot, Orgs: []string{"a"}}, want: []string{"providers[0].orgs"}},
+		{name: "filter topics on azure", config: domain.ProviderConfig{Provider: "azure", Org: "https://dev.azure.com/o/", Token: "t", Filter: domain.RepositoryFilter{Topics: []string{"service"}}}, want: []string{"providers[0].filter"}},
+		{name: "search discovery", config: domain.ProviderConfig{Provider: "gitlab", Org: "o", Token: "t", Discovery: "search"}},
+		{name: "search discovery on github", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", Discovery: "search", Filter: domain.RepositoryFilter{SkipForks: true}}},
+		{name: "search discovery on github with forks", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", Discovery: "search"}, want: []string{"providers[0].discovery"}},
+		{name: "search discovery on azure", config: domain.ProviderConfig{Provider: "azure", Org: "https://dev.azure.com/o/", Token: "t", Discovery: "search"}, want: []string{"providers[0].discovery"}},
+		{name: "unknown discovery", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", Discovery: "crawl"}, want: []string{"providers[0].discovery"}},
+		{name: "probe turned off", config: domain.ProviderConfig{Provider: "bitbucket", Org: "ws", Token: "t", ProbeIdentifier: boolPtr(false)}},
+		{name: "probe on local", config: domain.ProviderConfig{Provider: "local", Path: gitRoot, ProbeIdentifier: boolPtr(true)}, want: []string{"providers[0].probeIdentifier"}},
+		{name: "page size too large", config: domain.ProviderConfig{Provider: "github", Org: "o", Token: "t", PageSize: 500}, want: []string{"providers[0].pageSize"}},
+		{name: "page size on gitlab", config: domain.ProviderConfig{Provider: "gitlab", Org: "o", Token: "t", PageSize: 50}},
+		{name: "page size on azure", config: domain.ProviderConfig{Provider: "azure", Org: "https://dev.azure.com/o/", Token: "t", PageSize: 50}, want: []string{"providers[0].pageSize"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			problems := ValidateProviderConfig("providers[0]", tc.config)
+			if len(problems) != len(tc.want) {
+				t.Fatalf("expected %d problems, got %v", len(tc.want), problems)
+			}
+			for i, field := range tc.want {
+				if problems[i].Field != field {
+					t.Fatalf("expected problem for %s, got %s", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,206 @@
+package repository_providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// lowBudgetFraction is the share of the rate limit below which requests are spread out until the reset.
+const lowBudgetFraction = 0.1
+
+// Rate limit headers. GitHub and Azure DevOps use the X- prefixed names, GitLab the unprefixed ones.
+const (
+	headerRateLimit       = "X-RateLimit-Limit"
+	headerRateRemaining   = "X-RateLimit-Remaining"
+	headerRateReset       = "X-RateLimit-Reset"
+	headerGitlabRateLimit = "RateLimit-Limit"
+	headerGitlabRemaining = "RateLimit-Remaining"
+	headerGitlabRateReset = "RateLimit-Reset"
+	headerRetryAfter      = "Retry-After"
+	headerGithubResource  = "X-RateLimit-Resource"
+	githubCoreResource    = "core"
+)
+
+// rateBudget tracks the API rate limit reported by a provider for one token. Requests wait while the
+// budget is exhausted or a Retry-After is pending, and are spread out evenly once the budget runs low.
+type rateBudget struct {
+	name string
+	now  func() time.Time
+
+	mu         sync.Mutex
+	limit      int
+	remaining  int
+	reset      time.Time
+	known      bool
+	pauseUntil time.Time
+	warned     time.Time
+}
+
+var (
+	rateBudgetsMu sync.Mutex
+	rateBudgets   = map[string]*rateBudget{}
+)
+
+// budgetFor returns the shared budget for a provider instance and token. Providers are created per repository in the
+// pull request flow, so the budget lives at package level to be shared by every client using the same token.
+// instance is the provider's base URL, or "" for the public service.
+func budgetFor(provider, instance, token string) *rateBudget {
+	rateBudgetsMu.Lock()
+	defer rateBudgetsMu.Unlock()
+
+	key := provider + "\x00" + instance + "\x00" + token
+	b, ok := rateBudgets[key]
+	if !ok {
+		b = newRateBudget(provider)
+		rateBudgets[key] = b
+	}
+	return b
+}
+
+func newRateBudget(name string) *rateBudget {
+	return &rateBudget{name: name, now: time.Now}
+}
+
+// delay returns how long the next request should wait, and why.
+func (b *rateBudget) delay() (time.Duration, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if now.Before(b.pauseUntil) {
+		return b.pauseUntil.Sub(now), "retry-after requested"
+	}
+	if !b.known || !now.Before(b.reset) {
+		return 0, ""
+	}
+	if b.remaining <= 0 {
+		return b.reset.Sub(now), "rate limit exhausted"
+	}
+	if float64(b.remaining) < float64(b.limit)*lowBudgetFraction {
+		return b.reset.Sub(now) / time.Duration(b.remaining+1), "rate limit low"
+	}
+	return 0, ""
+}
+
+// wait blocks until the budget allows another request or ctx is done.
+func (b *rateBudget) wait(ctx context.Context) error {
+	d, reason := b.delay()
+	if d <= 0 {
+		return nil
+	}
+	if d >= time.Second {
+		fmt.Printf("%s %s, pausing for %s\n", b.name, reason, d.Round(time.Second))
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe records the rate limit headers of a response and logs the remaining budget once it runs low.
+func (b *rateBudget) observe(h http.Header) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if d, ok := retryAfter(h, now); ok {
+		b.pauseUntil = now.Add(d)
+	}
+
+	// GitHub reports separate budgets for search, GraphQL and so on; only the core REST budget is tracked.
+	// Azure DevOps sends the same header naming the throttled service (e.g. Core or ATCP) and has a single budget.
+	if resource := h.Get(headerGithubResource); b.name == ProviderGithub && resource != "" && resource != githubCoreResource {
+		return
+	}
+	limit, okLimit := headerInt(h, headerRateLimit, headerGitlabRateLimit)
+	remaining, okRemaining := headerInt(h, headerRateRemaining, headerGitlabRemaining)
+	if !okLimit || !okRemaining {
+		return
+	}
+	reset, _ := headerInt(h, headerRateReset, headerGitlabRateReset)
+
+	b.limit, b.remaining, b.known = limit, remaining, true
+	b.reset = time.Unix(int64(reset), 0)
+
+	if float64(remaining) < float64(limit)*lowBudgetFraction && !b.warned.Equal(b.reset) {
+		b.warned = b.reset
+		fmt.Printf("%s rate limit low: %d/%d requests remaining, resets at %s\n", b.name, remaining, limit, b.reset.Format(time.TimeOnly))
+	}
+}
+
+// rateLimitTransport waits on a rateBudget before each request and updates it from each response.
+type rateLimitTransport struct {
+	budget *rateBudget
+	base   http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.budget.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if resp != nil {
+		t.budget.observe(resp.Header)
+	}
+	return resp, err
+}
+
+// newRateLimitedClient returns an HTTP client whose requests are paced by budget.
+func newRateLimitedClient(budget *rateBudget) *http.Client {
+	return &http.Client{Transport: &rateLimitTransport{budget: budget, base: http.DefaultTransport}}
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	value := h.Get(headerRetryAfter)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(now), true
+	}
+	return 0, false
+}
+
+// rateLimitWait returns how long to wait before retrying a rate limited response: the Retry-After
+// header if present, otherwise the time until the rate limit resets when the budget is exhausted.
+func rateLimitWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if d, ok := retryAfter(resp.Header, now); ok {
+		return d, true
+	}
+	remaining, ok := headerInt(resp.Header, headerRateRemaining, headerGitlabRemaining)
+	if !ok || remaining > 0 {
+		return 0, false
+	}
+	reset, ok := headerInt(resp.Header, headerRateReset, headerGitlabRateReset)
+	if !ok {
+		return 0, false
+	}
+	return time.Unix(int64(reset), 0).Sub(now), true
+}
+
+// headerInt returns the first of names present in h parsed as an integer.
+func headerInt(h http.Header, names ...string) (int, bool) {
+	for _, name := range names {
+		if value := h.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
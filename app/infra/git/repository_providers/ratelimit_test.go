@@ -0,0 +1,143 @@
+package repository_providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+func rateHeaders(limit, remaining int, reset time.Time) http.Header {
+	h := http.Header{}
+	h.Set(headerRateLimit, strconv.Itoa(limit))
+	h.Set(headerRateRemaining, strconv.Itoa(remaining))
+	h.Set(headerRateReset, strconv.FormatInt(reset.Unix(), 10))
+	return h
+}
+
+func TestRateBudget_Delay(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	reset := now.Add(10 * time.Minute)
+
+	cases := []struct {
+		name     string
+		provider string
+		header   http.Header
+		want     time.Duration
+	}{
+		{name: "no headers", header: http.Header{}, want: 0},
+		{name: "plenty remaining", header: rateHeaders(5000, 4000, reset), want: 0},
+		{name: "exhausted", header: rateHeaders(5000, 0, reset), want: 10 * time.Minute},
+		{name: "low budget is spread until reset", header: rateHeaders(5000, 99, reset), want: 6 * time.Second},
+		{name: "reset already passed", header: rateHeaders(5000, 0, now.Add(-time.Second)), want: 0},
+		{name: "retry-after", header: http.Header{headerRetryAfter: []string{"30"}}, want: 30 * time.Second},
+		{name: "gitlab headers", header: func() http.Header {
+			h := http.Header{}
+			h.Set(headerGitlabRateLimit, "600")
+			h.Set(headerGitlabRemaining, "0")
+			h.Set(headerGitlabRateReset, strconv.FormatInt(reset.Unix(), 10))
+			return h
+		}(), want: 10 * time.Minute},
+		{name: "non-core github resource ignored", provider: ProviderGithub, header: func() http.Header {
+			h := rateHeaders(30, 0, reset)
+			h.Set(headerGithubResource, "search")
+			return h
+		}(), want: 0},
+		{name: "azure core throttled", provider: ProviderAzure, header: http.Header{
+			"X-Ratelimit-Resource":  []string{"Core"},
+			"X-Ratelimit-Delay":     []string{"1.253"},
+			"X-Ratelimit-Limit":     []string{"200"},
+			"X-Ratelimit-Remaining": []string{"0"},
+			"X-Ratelimit-Reset":     []string{strconv.FormatInt(reset.Unix(), 10)},
+		}, want: 10 * time.Minute},
+		{name: "azure atcp low", provider: ProviderAzure, header: http.Header{
+			"X-Ratelimit-Resource":  []string{"ATCP"},
+			"X-Ratelimit-Delay":     []string{"0.328"},
+			"X-Ratelimit-Limit":     []string{"200"},
+			"X-Ratelimit-Remaining": []string{"19"},
+			"X-Ratelimit-Reset":     []string{strconv.FormatInt(reset.Unix(), 10)},
+		}, want: 30 * time.Second},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newRateBudget(tc.provider)
+			b.now = func() time.Time { return now }
+			b.observe(tc.header)
+			if got, _ := b.delay(); got != tc.want {
+				t.Fatalf("expected delay %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransport_WaitsForRetryAfter(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set(headerRetryAfter, "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := newRateLimitedClient(newRateBudget("test"))
+	start := time.Now()
+	for range 2 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected the second request to wait for Retry-After, took %s", elapsed)
+	}
+
+	start = time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	budget := newRateBudget("test")
+	budget.observe(http.Header{headerRetryAfter: []string{"60"}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := (&rateLimitTransport{budget: budget, base: http.DefaultTransport}).RoundTrip(req); err == nil {
+		t.Fatalf("expected context error while paused for Retry-After")
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("expected cancellation to interrupt the pause")
+	}
+}
+
+func TestClassifyAPIError_RateLimits(t *testing.T) {
+	now := time.Now()
+	reset := now.Add(time.Minute)
+
+	primary := &github.RateLimitError{
+		Rate:     github.Rate{Limit: 5000, Remaining: 0, Reset: github.Timestamp{Time: reset}},
+		Response: &http.Response{StatusCode: http.StatusForbidden},
+	}
+	retry := 45 * time.Second
+	secondary := &github.AbuseRateLimitError{Response: &http.Response{StatusCode: http.StatusForbidden}, RetryAfter: &retry}
+	exhausted := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusForbidden, Header: rateHeaders(5000, 0, reset)}}
+	forbidden := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}}
+
+	for name, err := range map[string]error{"primary": primary, "secondary": secondary, "exhausted headers": exhausted} {
+		if !domain.IsTransient(classifyAPIError(err)) {
+			t.Fatalf("%s: expected rate limit error to be transient", name)
+		}
+		if d, ok := apiRateLimitWait(err, now); !ok || d <= 0 {
+			t.Fatalf("%s: expected a positive wait, got %s (ok=%v)", name, d, ok)
+		}
+	}
+	if domain.IsTransient(classifyAPIError(forbidden)) {
+		t.Fatalf("expected plain 403 to be permanent")
+	}
+}
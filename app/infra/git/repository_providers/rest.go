@@ -0,0 +1,95 @@
+package repository_providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody caps how much of an error response body is kept in a restError.
+const maxErrorBody = 512
+
+// restClient is a minimal JSON client for provider REST APIs that have no Go SDK in use here
+// (Bitbucket Cloud, Bitbucket Server and Gitea).
+type restClient struct {
+	http    *http.Client
+	baseURL string
+	auth    func(*http.Request)
+}
+
+// restError is returned for non-2xx responses. It keeps the response so the status code and
+// rate limit headers can be used to classify the error.
+type restError struct {
+	Method   string
+	URL      string
+	Body     string
+	Response *http.Response
+}
+
+func (e *restError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.Response.StatusCode, e.Body)
+}
+
+// newRestClient returns a client for baseURL whose requests are paced by budget and authorized by auth.
+func newRestClient(baseURL string, budget *rateBudget, auth func(*http.Request)) *restClient {
+	return &restClient{http: newRateLimitedClient(budget), baseURL: strings.TrimRight(baseURL, "/"), auth: auth}
+}
+
+// bearerAuth sets a bearer token on each request.
+func bearerAuth(token string) func(*http.Request) {
+	return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+}
+
+// basicAuth sets HTTP basic credentials on each request.
+func basicAuth(username, password string) func(*http.Request) {
+	return func(req *http.Request) { req.SetBasicAuth(username, password) }
+}
+
+// do sends body as JSON to path, relative to the base URL unless it is already absolute,
+// and decodes the response into out when out is not nil.
+func (c *restClient) do(ctx context.Context, method, path string, body, out any) error {
+	target := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		target = c.baseURL + "/" + strings.TrimLeft(path, "/")
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		c.auth(req)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &restError{Method: method, URL: target, Body: strings.TrimSpace(string(data)), Response: resp}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
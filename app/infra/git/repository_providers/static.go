@@ -0,0 +1,107 @@
+package repository_providers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// staticPullRequestProviders are the provider types a static repository can use to open pull requests.
+// They identify a repository by owner and name, both of which can be taken from its clone URL.
+var staticPullRequestProviders = []string{ProviderGithub, ProviderGitlab, ProviderBitbucket, ProviderGitea}
+
+// StaticRepositoryProvider returns the repositories listed in its config without calling any API.
+// Pull requests are delegated to the provider named by each repository's pullRequestProvider,
+// using the owner from the clone URL as its org and this entry's token, username and baseUrl.
+type StaticRepositoryProvider struct {
+	config      domain.ProviderConfig
+	newProvider func(domain.ProviderConfig) (domain.GitRepositoryProvider, error)
+}
+
+func (s StaticRepositoryProvider) GetRepositories(_ context.Context) (*[]domain.GitRepository, error) {
+	output := make([]domain.GitRepository, 0, len(s.config.Repositories))
+	for _, repo := range s.config.Repositories {
+		output = append(output, domain.GitRepository{Name: staticRepoName(repo), Url: repo.Url})
+	}
+	return &output, nil
+}
+
+// CreatePullRequest opens a pull request through the repository's pullRequestProvider.
+func (s StaticRepositoryProvider) CreatePullRequest(ctx context.Context, repo, baseBranch, headBranch, title string, filesChanged []string, originalAuthor string, buildBody domain.PRBodyBuilder) (domain.PRInfo, error) {
+	prMgr, name, err := s.pullRequestManager(repo)
+	if err != nil {
+		return domain.PRInfo{}, err
+	}
+	return prMgr.CreatePullRequest(ctx, name, baseBranch, headBranch, title, filesChanged, originalAuthor, buildBody)
+}
+
+// AssignReviewers assigns reviewers through the repository's pullRequestProvider.
+func (s StaticRepositoryProvider) AssignReviewers(ctx context.Context, repo string, pr domain.PRInfo, reviewers []string) error {
+	prMgr, name, err := s.pullRequestManager(repo)
+	if err != nil {
+		return err
+	}
+	return prMgr.AssignReviewers(ctx, name, pr, reviewers)
+}
+
+// pullRequestManager builds the delegate provider for the static repository called repo and returns
+// it with the repository name that provider expects.
+func (s StaticRepositoryProvider) pullRequestManager(repo string) (domain.PullRequestManager, string, error) {
+	for _, r := range s.config.Repositories {
+		if staticRepoName(r) != repo {
+			continue
+		}
+		if r.PullRequestProvider == "" {
+			return nil, "", fmt.Errorf("static repository %s has no pullRequestProvider", repo)
+		}
+		owner, name := repoURLParts(r.Url)
+		prov, err := s.newProvider(domain.ProviderConfig{
+			Provider: r.PullRequestProvider,
+			Username: s.config.Username,
+			Org:      owner,
+			Token:    s.config.Token,
+			BaseURL:  s.config.BaseURL,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		prMgr, ok := prov.(domain.PullRequestManager)
+		if !ok {
+			return nil, "", fmt.Errorf("provider %s does not support pull requests", r.PullRequestProvider)
+		}
+		return prMgr, name, nil
+	}
+	return nil, "", fmt.Errorf("unknown static repository %s", repo)
+}
+
+// staticRepoName returns the configured name of repo, or the last path segment of its URL.
+func staticRepoName(repo domain.StaticRepository) string {
+	if repo.Name != "" {
+		return repo.Name
+	}
+	_, name := repoURLParts(repo.Url)
+	return name
+}
+
+// repoURLParts splits a clone URL such as https://host/group/sub/repo.git or git@host:owner/repo.git
+// into its owner path (group/sub) and repository name (repo).
+func repoURLParts(rawURL string) (owner, name string) {
+	p := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		p = u.Path
+	} else if _, rest, ok := strings.Cut(rawURL, ":"); ok {
+		p = rest
+	}
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	owner, name = path.Split(p)
+	return strings.Trim(owner, "/"), name
+}
+
+// NewStaticRepositoryProvider creates a provider returning config.Repositories.
+func NewStaticRepositoryProvider(config domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+	return &StaticRepositoryProvider{config: config, newProvider: NewProvider}, nil
+}
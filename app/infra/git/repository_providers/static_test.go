@@ -0,0 +1,101 @@
+package repository_providers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// fakePRProvider records the pull request calls delegated to it.
+type fakePRProvider struct {
+	repos []string
+}
+
+func (f *fakePRProvider) GetRepositories(context.Context) (*[]domain.GitRepository, error) {
+	return &[]domain.GitRepository{}, nil
+}
+
+func (f *fakePRProvider) CreatePullRequest(_ context.Context, repo, _, _, _ string, _ []string, _ string, _ domain.PRBodyBuilder) (domain.PRInfo, error) {
+	f.repos = append(f.repos, repo)
+	return domain.PRInfo{ID: 1}, nil
+}
+
+func (f *fakePRProvider) AssignReviewers(_ context.Context, repo string, _ domain.PRInfo, _ []string) error {
+	f.repos = append(f.repos, repo)
+	return nil
+}
+
+func TestStaticProvider_GetRepositories(t *testing.T) {
+	p, err := NewStaticRepositoryProvider(domain.ProviderConfig{Provider: ProviderStatic, Repositories: []domain.StaticRepository{
+		{Url: "https://github.com/alice/tool.git"},
+		{Url: "git@git.example.com:team/service.git", Name: "svc"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := p.GetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GetRepositories unexpected error: %v", err)
+	}
+	want := []domain.GitRepository{
+		{Name: "tool", Url: "https://github.com/alice/tool.git"},
+		{Name: "svc", Url: "git@git.example.com:team/service.git"},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("GetRepositories mismatch\nGot:  %#v\nWant: %#v", *got, want)
+	}
+}
+
+func TestStaticProvider_DelegatesPullRequests(t *testing.T) {
+	fake := &fakePRProvider{}
+	var created []domain.ProviderConfig
+	p := StaticRepositoryProvider{
+		config: domain.ProviderConfig{Provider: ProviderStatic, Token: "t", Repositories: []domain.StaticRepository{
+			{Url: "https://gitlab.com/group/sub/tool.git", PullRequestProvider: ProviderGitlab},
+			{Url: "https://git.example.com/plain.git"},
+		}},
+		newProvider: func(c domain.ProviderConfig) (domain.GitRepositoryProvider, error) {
+			created = append(created, c)
+			return fake, nil
+		},
+	}
+
+	pr, err := p.CreatePullRequest(context.Background(), "tool", "main", "head", "title", nil, "", nil)
+	if err != nil {
+		t.Fatalf("CreatePullRequest unexpected error: %v", err)
+	}
+	if err := p.AssignReviewers(context.Background(), "tool", pr, []string{"alice"}); err != nil {
+		t.Fatalf("AssignReviewers unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(fake.repos, []string{"tool", "tool"}) {
+		t.Fatalf("unexpected delegated repos: %v", fake.repos)
+	}
+	if created[0].Provider != ProviderGitlab || created[0].Org != "group/sub" || created[0].Token != "t" {
+		t.Fatalf("unexpected delegate config: %+v", created[0])
+	}
+
+	if _, err := p.CreatePullRequest(context.Background(), "plain", "main", "head", "title", nil, "", nil); err == nil {
+		t.Fatalf("expected error for repository without pullRequestProvider")
+	}
+}
+
+func TestRepoURLParts(t *testing.T) {
+	cases := map[string][2]string{
+		"https://github.com/alice/tool.git":           {"alice", "tool"},
+		"https://gitlab.com/group/sub/tool":           {"group/sub", "tool"},
+		"git@github.com:alice/tool.git":               {"alice", "tool"},
+		"file:///srv/git/mirrors/tool.git":            {"srv/git/mirrors", "tool"},
+		"https://git.example.com/plain.git/":          {"", "plain"},
+		"https://user@bitbucket.org/ws/repo-one.git":  {"ws", "repo-one"},
+		"ssh://git@git.example.com:2222/team/svc.git": {"team", "svc"},
+	}
+	for raw, want := range cases {
+		owner, name := repoURLParts(raw)
+		if owner != want[0] || name != want[1] {
+			t.Fatalf("%s: expected %v, got [%s %s]", raw, want, owner, name)
+		}
+	}
+}
@@ -2,8 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/file"
@@ -22,6 +28,9 @@ var conf = koanf.Conf{
 var k = koanf.NewWithConf(conf)
 
 func runWithArgs(args []string) error {
+	// Clones and pushes use GitHub App installation tokens when a provider is configured with an app.
+	git.SetCredentialResolver(repository_providers.GitCredentials)
+
 	cmd := &cli.Command{
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -30,37 +39,59 @@ func runWithArgs(args []string) error {
 				Usage:   "The config file to be used",
 				Aliases: []string{"c"},
 			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "Maximum number of repositories processed at once (overrides run.concurrency)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "host-concurrency",
+				Usage: "Per-host concurrency limit as host=limit, e.g. github.com=2 (repeatable, overrides run.hosts)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "provider-concurrency",
+				Usage: "Per-provider concurrency limit as provider=limit, e.g. gitlab=2 (repeatable, overrides providers[].concurrency for every entry of that provider)",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Maximum duration of the whole run, e.g. 30m (overrides run.timeout)",
+			},
+			&cli.DurationFlag{
+				Name:  "repo-timeout",
+				Usage: "Maximum duration for processing a single repository, e.g. 5m (overrides run.repoTimeout)",
+			},
+			&cli.IntFlag{
+				Name:  "retries",
+				Usage: "Total attempts for transient clone, push and provider API failures, 1 disables retries (overrides retry.attempts)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "repo",
+				Usage: "Only process the repository with this exact name or path, e.g. my-repo or my-org/my-repo (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "match",
+				Usage: "Only process repositories whose name or path matches this glob, or regex when wrapped in slashes, e.g. 'svc-*' or '/^svc-.*$/' (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "provider",
+				Usage: "Only discover repositories from providers of this type, e.g. github (repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Show the changes that would be made without committing, pushing or opening pull requests (same as plan)",
+				Local: true,
+			},
 		},
 		EnableShellCompletion: true,
 		Name:                  "run",
 		Usage:                 "Run BoneClone",
 		Action: func(cxt context.Context, c *cli.Command) error {
-			configFile := c.String("config")
-
-			if err := k.Load(file.Provider(configFile), yaml.Parser()); err != nil {
-				log.Fatalf("error loading config: %v", err)
+			if c.Bool("dry-run") {
+				return planAction(cxt, c)
 			}
 
-			// Expand environment variables in config values before unmarshalling
-			if err := expandEnvValues(k); err != nil {
-				log.Fatalf("error expanding env in config: %v", err)
-			}
-
-			// Set defaults for missing config values
-			if !k.Exists("git.pullRequest") {
-				if err := k.Set("git.pullRequest", true); err != nil {
-					log.Fatalf("error setting default git.pullRequest: %v", err)
-				}
-			}
-			if !k.Exists("git.targetBranch") {
-				if err := k.Set("git.targetBranch", "main"); err != nil {
-					log.Fatalf("error setting default git.targetBranch: %v", err)
-				}
-			}
-
-			var config domain.Config
-			if err := k.Unmarshal("", &config); err != nil {
-				log.Fatalf("error unmarshalling config: %v", err)
+			config, err := loadConfig(c)
+			if err != nil {
+				return err
 			}
 
 			// Configure skeleton name for PR messages (used in PR body)
@@ -68,16 +99,172 @@ func runWithArgs(args []string) error {
 
 			ops := git.NewOperations()
 			processor := domain.NewProcessorForConfig(config, ops, repository_providers.NewProvider)
-			return domain.Run(cxt, config, repository_providers.NewProvider, processor)
+			report, err := domain.Run(cxt, config, repository_providers.NewProvider, processor)
+			if werr := writeReport(os.Stdout, report); werr != nil {
+				return werr
+			}
+			if err != nil {
+				return err
+			}
+			return reportExitError(report)
+		},
+		Commands: []*cli.Command{
+			{
+				Name:   "plan",
+				Usage:  "Show the changes BoneClone would make to each repository without committing, pushing or opening pull requests",
+				Action: planAction,
+			},
+			{
+				Name:  "diff",
+				Usage: "Print the unified diff BoneClone would apply to each repository without pushing",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output-dir",
+						Usage:   "Write one .patch file per repository into this directory instead of printing to stdout",
+						Aliases: []string{"o"},
+					},
+				},
+				Action: diffAction,
+			},
+			{
+				Name:  "list",
+				Usage: "List discovered repositories with their identifier file, acceptance status and reviewers",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: formatTable,
+						Usage: "Output format: table or json",
+					},
+				},
+				Action: listAction,
+			},
+			{
+				Name:   "validate",
+				Usage:  "Check the config file and report every problem found",
+				Action: validateAction,
+			},
 		},
 	}
 
-	return cmd.Run(context.Background(), args)
+	// Ctrl-C or a CI job termination cancels the context so in-flight clones and pushes stop cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return cmd.Run(ctx, args)
+}
+
+// planAction clones and validates every repository and applies the copy in memory, printing the resulting changes.
+func planAction(ctx context.Context, c *cli.Command) error {
+	config, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := domain.Run(ctx, config, repository_providers.NewProvider, domain.NewPlanProcessor(git.NewOperations()))
+	if werr := writePlan(os.Stdout, report); werr != nil {
+		return werr
+	}
+	if err != nil {
+		return err
+	}
+	return reportExitError(report)
+}
+
+// diffAction renders the in-memory changes for every repository as unified diffs.
+func diffAction(ctx context.Context, c *cli.Command) error {
+	config, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := domain.Run(ctx, config, repository_providers.NewProvider, domain.NewDiffProcessor(git.NewOperations()))
+	if dir := c.String("output-dir"); dir != "" {
+		if werr := writePatchFiles(os.Stdout, dir, report); werr != nil {
+			return werr
+		}
+	} else if werr := writeDiffs(os.Stdout, report); werr != nil {
+		return werr
+	}
+	if err != nil {
+		return err
+	}
+	return reportExitError(report)
+}
+
+// listAction reports every discovered repository and whether it accepts the configured skeleton.
+func listAction(ctx context.Context, c *cli.Command) error {
+	format := c.String("format")
+	if format != formatTable && format != formatJSON {
+		return fmt.Errorf("unknown format %q: expected %s or %s", format, formatTable, formatJSON)
+	}
+
+	config, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := domain.Run(ctx, config, repository_providers.NewProvider, domain.NewInspectProcessor(git.NewOperations()))
+	if werr := writeList(os.Stdout, report, format); werr != nil {
+		return werr
+	}
+	if err != nil {
+		return err
+	}
+	return reportExitError(report)
+}
+
+// loadConfig loads the config file selected by --config and applies command line overrides.
+func loadConfig(c *cli.Command) (domain.Config, error) {
+	config, err := loadConfigFile(c.String("config"))
+	if err != nil {
+		return config, err
+	}
+	if err := applyRunFlags(c, &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// loadConfigFile reads configFile into the global koanf instance, expands environment variables,
+// applies defaults and unmarshals the result.
+func loadConfigFile(configFile string) (domain.Config, error) {
+	var config domain.Config
+
+	if err := k.Load(file.Provider(configFile), yaml.Parser()); err != nil {
+		return config, fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Expand environment variables in config values before unmarshalling
+	if err := expandEnvValues(k); err != nil {
+		return config, fmt.Errorf("error expanding env in config: %w", err)
+	}
+
+	// Set defaults for missing config values
+	if !k.Exists("git.pullRequest") {
+		if err := k.Set("git.pullRequest", true); err != nil {
+			return config, fmt.Errorf("error setting default git.pullRequest: %w", err)
+		}
+	}
+	if !k.Exists("git.targetBranch") {
+		if err := k.Set("git.targetBranch", "main"); err != nil {
+			return config, fmt.Errorf("error setting default git.targetBranch: %w", err)
+		}
+	}
+
+	if err := k.Unmarshal("", &config); err != nil {
+		return config, fmt.Errorf("error unmarshalling config: %w", err)
+	}
+	return config, nil
 }
 
 func main() {
 	if err := runWithArgs(os.Args); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		var ee *exitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.code)
+		}
+		os.Exit(exitCodeError)
 	}
 }
 
@@ -96,3 +283,79 @@ func expandEnvValues(k *koanf.Koanf) error {
 	}
 	return nil
 }
+
+// applyRunFlags overrides run settings from the config file with any values given on the command line.
+func applyRunFlags(c *cli.Command, config *domain.Config) error {
+	if c.IsSet("concurrency") {
+		if c.Int("concurrency") <= 0 {
+			return fmt.Errorf("invalid --concurrency %d: must be at least 1", c.Int("concurrency"))
+		}
+		config.Run.Concurrency = c.Int("concurrency")
+	}
+	if c.IsSet("timeout") {
+		config.Run.Timeout = c.Duration("timeout")
+	}
+	if c.IsSet("repo-timeout") {
+		config.Run.RepoTimeout = c.Duration("repo-timeout")
+	}
+	if c.IsSet("retries") {
+		config.Retry.Attempts = c.Int("retries")
+	}
+	if c.IsSet("repo") {
+		config.Run.Targets.Repos = c.StringSlice("repo")
+	}
+	if c.IsSet("match") {
+		config.Run.Targets.Match = c.StringSlice("match")
+	}
+	if c.IsSet("provider") {
+		config.Run.Targets.Providers = c.StringSlice("provider")
+	}
+	if c.IsSet("provider-concurrency") {
+		for _, value := range c.StringSlice("provider-concurrency") {
+			name, n, err := parseLimit("provider-concurrency", "provider", value)
+			if err != nil {
+				return err
+			}
+			matched := false
+			for i := range config.Providers {
+				if strings.EqualFold(strings.TrimSpace(config.Providers[i].Provider), name) {
+					config.Providers[i].Concurrency = n
+					matched = true
+				}
+			}
+			if !matched {
+				return fmt.Errorf("invalid --provider-concurrency %q: no %s provider is configured", value, name)
+			}
+		}
+	}
+	if !c.IsSet("host-concurrency") {
+		return nil
+	}
+
+	hosts := make([]domain.HostLimit, 0, len(c.StringSlice("host-concurrency")))
+	for _, value := range c.StringSlice("host-concurrency") {
+		host, n, err := parseLimit("host-concurrency", "host", value)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, domain.HostLimit{Host: host, Concurrency: n})
+	}
+	config.Run.Hosts = hosts
+	return nil
+}
+
+// parseLimit splits a key=limit flag value, e.g. github.com=2, naming the flag and key in errors.
+func parseLimit(flag, key, value string) (string, int, error) {
+	name, limit, ok := strings.Cut(value, "=")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid --%s %q: expected %s=limit", flag, value, key)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid --%s %q: %w", flag, value, err)
+	}
+	if n <= 0 {
+		return "", 0, fmt.Errorf("invalid --%s %q: limit must be at least 1", flag, value)
+	}
+	return strings.TrimSpace(name), n, nil
+}
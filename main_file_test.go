@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/knadh/koanf/v2"
+)
+
+func TestRun_LocalProviderPushesToBareRepo(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	// Seed a bare repository that accepts the skeleton.
+	src := t.TempDir()
+	seed, err := git.PlainInit(src, false, git.WithDefaultBranch(plumbing.NewBranchReferenceName("main")))
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, ".boneclone"), []byte("accepts:\n  - Skeleton\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	wt, _ := seed.Worktree()
+	if _, err := wt.Add(".boneclone"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := wt.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "t", Email: "t@example.org", When: time.Now()}}); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	mirrors := t.TempDir()
+	if _, err := git.PlainClone(filepath.Join(mirrors, "service.git"), &git.CloneOptions{URL: src, Bare: true}); err != nil {
+		t.Fatalf("bare clone: %v", err)
+	}
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir(filepath.Join(dir, "ci"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ci", "build.sh"), []byte("echo build\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfgPath := writeTempConfig(t, dir, `providers:
+  - provider: local
+    path: `+mirrors+`
+files:
+  include:
+    - ci
+identifier:
+  filename: .boneclone
+  name: Skeleton
+git:
+  pullRequest: false
+  targetBranch: main
+`)
+
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath}); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	pushed, err := git.PlainOpen(filepath.Join(mirrors, "service.git"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	head, err := pushed.Reference(plumbing.NewBranchReferenceName("main"), true)
+	if err != nil {
+		t.Fatalf("reference: %v", err)
+	}
+	commit, err := pushed.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if _, err := commit.File("ci/build.sh"); err != nil {
+		t.Fatalf("expected ci/build.sh to be pushed: %v", err)
+	}
+}
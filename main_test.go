@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/knadh/koanf/v2"
+
+	"go.iain.rocks/boneclone/app/domain"
 )
 
 // writeTempConfig writes the provided YAML string to a temp file and returns its path.
@@ -52,3 +58,305 @@ func TestRun_WithDefaultConfigPath_NoProviders(t *testing.T) {
 		t.Fatalf("runWithArgs returned error: %v", err)
 	}
 }
+
+func TestRun_InvalidConcurrencyFlags(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	dir := t.TempDir()
+	cfgPath := writeTempConfig(t, dir, "providers: []\n")
+
+	for _, args := range [][]string{
+		{"--host-concurrency", "github.com"},
+		{"--host-concurrency", "github.com=0"},
+		{"--concurrency", "0"},
+		{"--concurrency", "-2"},
+	} {
+		k = koanf.NewWithConf(conf)
+		if err := runWithArgs(append([]string{"boneclone", "-c", cfgPath}, args...)); err == nil || !strings.Contains(err.Error(), args[0]) {
+			t.Fatalf("expected error for %v, got %v", args, err)
+		}
+	}
+}
+
+func TestReportExitError_MapsStatusToExitCode(t *testing.T) {
+	if err := reportExitError(domain.RunReport{Results: []domain.RepoResult{{Outcome: domain.OutcomePushed}}}); err != nil {
+		t.Fatalf("expected nil error for successful run, got %v", err)
+	}
+
+	partial := domain.RunReport{Results: []domain.RepoResult{{Outcome: domain.OutcomePushed}, {Outcome: domain.OutcomeFailed}}}
+	var ee *exitError
+	if err := reportExitError(partial); !errors.As(err, &ee) || ee.code != exitCodePartialFailure {
+		t.Fatalf("expected partial failure exit code, got %v", err)
+	}
+
+	total := domain.RunReport{Results: []domain.RepoResult{{Outcome: domain.OutcomeFailed}}}
+	if err := reportExitError(total); !errors.As(err, &ee) || ee.code != exitCodeTotalFailure {
+		t.Fatalf("expected total failure exit code, got %v", err)
+	}
+}
+
+func TestPlan_NoProviders(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	dir := t.TempDir()
+	cfgPath := writeTempConfig(t, dir, "providers: []\n")
+
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "plan"}); err != nil {
+		t.Fatalf("plan returned error: %v", err)
+	}
+}
+
+func TestRun_DryRunFlag_NoProviders(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	dir := t.TempDir()
+	cfgPath := writeTempConfig(t, dir, "providers: []\n")
+
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "--dry-run"}); err != nil {
+		t.Fatalf("runWithArgs --dry-run returned error: %v", err)
+	}
+}
+
+func TestRun_MissingConfigReturnsError(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if err := runWithArgs([]string{"boneclone", "-c", missing}); err == nil {
+		t.Fatalf("expected error for missing config file")
+	}
+}
+
+func TestDiff_NoProviders_WithOutputDir(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	dir := t.TempDir()
+	cfgPath := writeTempConfig(t, dir, "providers: []\n")
+	out := filepath.Join(dir, "patches")
+
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "diff", "-o", out}); err != nil {
+		t.Fatalf("diff returned error: %v", err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Fatalf("expected output dir to be created: %v", err)
+	}
+}
+
+func TestPatchFileName(t *testing.T) {
+	repo := domain.GitRepository{Name: "repo", Url: "https://github.com/my-org/my.repo.git"}
+	if got := patchFileName(repo); got != "github.com_my-org_my.repo.patch" {
+		t.Fatalf("unexpected patch file name: %q", got)
+	}
+}
+
+func TestWritePatchFiles(t *testing.T) {
+	report := domain.RunReport{Results: []domain.RepoResult{
+		{Provider: "github", Repo: domain.GitRepository{Name: "a", Url: "https://github.com/o/a.git"}, Outcome: domain.OutcomeAccepted, Diff: "--- a/x\n+++ b/x\n"},
+		{Provider: "github", Repo: domain.GitRepository{Name: "b", Url: "https://github.com/o/b.git"}, Outcome: domain.OutcomeFailed, Err: errors.New("boom")},
+	}}
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	if err := writePatchFiles(&buf, dir, report); err != nil {
+		t.Fatalf("writePatchFiles: %v", err)
+	}
+	name := filepath.Join(dir, "github.com_o_a.patch")
+	if _, err := os.Stat(name); err != nil {
+		t.Fatalf("expected patch file: %v", err)
+	}
+	want := "wrote " + name + "\ngithub https://github.com/o/b.git: failed boom\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteList_JSON(t *testing.T) {
+	report := domain.RunReport{Results: []domain.RepoResult{
+		{Provider: "github", Repo: domain.GitRepository{Name: "a", Url: "https://github.com/o/a.git"}, Outcome: domain.OutcomeAccepted, Remote: domain.RemoteConfig{Reviewers: []string{"alice"}}},
+		{Provider: "github", Repo: domain.GitRepository{Name: "b", Url: "https://github.com/o/b.git"}, Outcome: domain.OutcomeNoIdentifier},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeList(&buf, report, formatJSON); err != nil {
+		t.Fatalf("writeList: %v", err)
+	}
+	var got []listEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, buf.String())
+	}
+	if len(got) != 2 || !got[0].Identifier || !got[0].Accepted || got[0].Reviewers[0] != "alice" || got[1].Identifier || got[1].Accepted {
+		t.Fatalf("unexpected list output: %+v", got)
+	}
+}
+
+func TestList_RejectsUnknownFormat(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	dir := t.TempDir()
+	cfgPath := writeTempConfig(t, dir, "providers: []\n")
+
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "list", "--format", "xml"}); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("BONECLONE_TEST_TOKEN", "")
+	cfgPath := writeTempConfig(t, dir, `providers:
+  - provider: githib
+    org: acme
+    token: x
+  - provider: github
+    org: acme
+    tokn: ${BONECLONE_TEST_TOKEN}
+files:
+  include:
+    - missing-dir
+identifier:
+  filename: .boneclone
+`)
+
+	var ee *exitError
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "validate"}); !errors.As(err, &ee) {
+		t.Fatalf("expected validation failure, got %v", err)
+	}
+
+	k = koanf.NewWithConf(conf)
+	config, err := loadConfigFile(cfgPath)
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+	got := map[string]bool{}
+	for _, p := range validateConfig(config) {
+		got[p.Field] = true
+	}
+	for _, field := range []string{
+		"providers[0].provider",
+		"providers[1].tokn",
+		"providers[1].token",
+		"files.include[0]",
+		"identifier.name",
+	} {
+		if !got[field] {
+			t.Fatalf("expected problem for %s, got %v", field, got)
+		}
+	}
+}
+
+func TestUnknownKeyProblems_ReportsDecodeErrors(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+	if err := k.Set("run.concurrency", "many"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	problems := unknownKeyProblems()
+	if len(problems) != 1 || problems[0].Field != "config" {
+		t.Fatalf("expected a single decode problem, got %v", problems)
+	}
+}
+
+func TestValidate_ValidConfig(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir(filepath.Join(dir, "ci"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	cfgPath := writeTempConfig(t, dir, `providers:
+  - provider: github
+    org: acme
+    token: x
+files:
+  include:
+    - ci
+identifier:
+  filename: .boneclone
+  name: Skeleton
+`)
+
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "validate"}); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+}
+
+func TestValidate_ProviderConcurrencyFlag(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir(filepath.Join(dir, "ci"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	cfgPath := writeTempConfig(t, dir, `providers:
+  - provider: github
+    org: acme
+    token: x
+files:
+  include:
+    - ci
+identifier:
+  filename: .boneclone
+  name: Skeleton
+`)
+
+	// Reset global koanf instance before every run to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "--provider-concurrency", "GitHub=2", "validate"}); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	// The flag replaces providers[].concurrency, so a negative limit is reported against the entry.
+	k = koanf.NewWithConf(conf)
+	var ee *exitError
+	if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "--provider-concurrency", "github=-1", "validate"}); !errors.As(err, &ee) {
+		t.Fatalf("expected validation failure, got %v", err)
+	}
+
+	// Malformed values, limits below 1 and unconfigured providers fail while loading the config, before any discovery.
+	for _, value := range []string{"github", "github=x", "gitlab=2", "github=0", "gitlab=-1"} {
+		k = koanf.NewWithConf(conf)
+		if err := runWithArgs([]string{"boneclone", "-c", cfgPath, "--provider-concurrency", value}); err == nil || !strings.Contains(err.Error(), "--provider-concurrency") {
+			t.Fatalf("expected flag error for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestValidate_LocalProvider(t *testing.T) {
+	// Reset global koanf instance to avoid cross-test state.
+	k = koanf.NewWithConf(conf)
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfgPath := writeTempConfig(t, dir, `providers:
+  - provider: local
+    path: missing
+files:
+  include:
+    - .
+identifier:
+  filename: .boneclone
+  name: Skeleton
+`)
+
+	config, err := loadConfigFile(cfgPath)
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+	got := map[string]bool{}
+	for _, p := range validateConfig(config) {
+		got[p.Field] = true
+	}
+	if !got["providers[0].path"] || !got["git.pullRequest"] || len(got) != 2 {
+		t.Fatalf("expected path and pullRequest problems, got %v", got)
+	}
+}
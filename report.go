@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"go.iain.rocks/boneclone/app/domain"
+)
+
+// Exit codes returned by the CLI when a run finishes with failures.
+const (
+	exitCodeError          = 1
+	exitCodePartialFailure = 2
+	exitCodeTotalFailure   = 3
+)
+
+// Output formats supported by the list command.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// exitError carries a specific process exit code out of a command action.
+type exitError struct {
+	code int
+	msg  string
+}
+
+func (e *exitError) Error() string { return e.msg }
+
+// reportExitError maps a run report to the error returned by the CLI, or nil when nothing failed.
+func reportExitError(report domain.RunReport) error {
+	switch report.Status() {
+	case domain.RunPartiallyFailed:
+		return &exitError{code: exitCodePartialFailure, msg: fmt.Sprintf("%d failure(s) during run", report.Failures())}
+	case domain.RunFailed:
+		return &exitError{code: exitCodeTotalFailure, msg: fmt.Sprintf("run failed: %d failure(s) and no successful repositories", report.Failures())}
+	default:
+		return nil
+	}
+}
+
+// writeReport prints one line per repository and provider failure followed by a summary of outcomes.
+func writeReport(w io.Writer, report domain.RunReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROVIDER\tREPOSITORY\tOUTCOME\tRETRIES\tDETAIL")
+	for _, f := range report.ProviderFailures {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t-\t%v\n", f.Provider, f.Org, domain.OutcomeFailed, &domain.StageError{Stage: f.Stage, Err: f.Err})
+	}
+	for _, res := range report.Results {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", res.Provider, res.Repo.Url, res.Outcome, res.Retries, resultDetail(res))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d repositories: %d pushed, %d pull requests opened, %d up to date, %d not accepted, %d without identifier, %d failed, %d retries\n",
+		len(report.Results),
+		report.Count(domain.OutcomePushed),
+		report.Count(domain.OutcomePROpened),
+		report.Count(domain.OutcomeUpToDate),
+		report.Count(domain.OutcomeNotAccepted),
+		report.Count(domain.OutcomeNoIdentifier),
+		report.Failures(),
+		report.Retries(),
+	)
+	return err
+}
+
+// writePlan prints, per repository, the files a run would add, modify or leave unchanged.
+func writePlan(w io.Writer, report domain.RunReport) error {
+	for _, f := range report.ProviderFailures {
+		if _, err := fmt.Fprintf(w, "%s %s: %s %v\n", f.Provider, f.Org, domain.OutcomeFailed, &domain.StageError{Stage: f.Stage, Err: f.Err}); err != nil {
+			return err
+		}
+	}
+	for _, res := range report.Results {
+		line := fmt.Sprintf("%s %s: %s", res.Provider, res.Repo.Url, res.Outcome)
+		if res.Err != nil {
+			line += " " + res.Err.Error()
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		for _, c := range res.Changes {
+			if _, err := fmt.Fprintf(w, "    %s %s (%s)\n", changeMarker(c.Status), c.Path, c.Status); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d repositories: %d would be updated, %d up to date, %d not accepted, %d without identifier, %d failed\n",
+		len(report.Results),
+		report.Count(domain.OutcomeWouldUpdate),
+		report.Count(domain.OutcomeUpToDate),
+		report.Count(domain.OutcomeNotAccepted),
+		report.Count(domain.OutcomeNoIdentifier),
+		report.Failures(),
+	)
+	return err
+}
+
+// writeDiffs prints the unified diff of every repository with changes, followed by any failures.
+func writeDiffs(w io.Writer, report domain.RunReport) error {
+	for _, res := range report.Results {
+		if res.Diff == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "# %s %s\n%s\n", res.Provider, res.Repo.Url, res.Diff); err != nil {
+			return err
+		}
+	}
+	return writeFailures(w, report)
+}
+
+// writePatchFiles writes one .patch file per repository with changes into dir, creating it if needed,
+// and reports each file written and every failure to w.
+func writePatchFiles(w io.Writer, dir string, report domain.RunReport) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, res := range report.Results {
+		if res.Diff == "" {
+			continue
+		}
+		name := filepath.Join(dir, patchFileName(res.Repo))
+		if err := os.WriteFile(name, []byte(res.Diff), 0o644); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "wrote %s\n", name); err != nil {
+			return err
+		}
+	}
+	return writeFailures(w, report)
+}
+
+// writeFailures prints failed providers and repositories so they are not lost when only diffs are shown.
+func writeFailures(w io.Writer, report domain.RunReport) error {
+	for _, f := range report.ProviderFailures {
+		if _, err := fmt.Fprintf(w, "%s %s: %s %v\n", f.Provider, f.Org, domain.OutcomeFailed, &domain.StageError{Stage: f.Stage, Err: f.Err}); err != nil {
+			return err
+		}
+	}
+	for _, res := range report.Results {
+		if res.Outcome != domain.OutcomeFailed {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s %s: %s %v\n", res.Provider, res.Repo.Url, res.Outcome, res.Err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var unsafePatchChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// patchFileName derives a unique, filesystem-safe file name from the repository clone URL.
+func patchFileName(repo domain.GitRepository) string {
+	name := repo.Name
+	if u, err := url.Parse(repo.Url); err == nil && u.Host != "" {
+		name = u.Host + "/" + strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	}
+	return strings.Trim(unsafePatchChars.ReplaceAllString(name, "_"), "_") + ".patch"
+}
+
+// listEntry is the JSON representation of a repository in the list command output.
+type listEntry struct {
+	Provider   string   `json:"provider"`
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Identifier bool     `json:"identifier"`
+	Accepted   bool     `json:"accepted"`
+	Reviewers  []string `json:"reviewers"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// writeList prints discovered repositories with their identifier and acceptance status as a table or JSON.
+func writeList(w io.Writer, report domain.RunReport, format string) error {
+	entries := make([]listEntry, 0, len(report.Results)+len(report.ProviderFailures))
+	for _, f := range report.ProviderFailures {
+		entries = append(entries, listEntry{Provider: f.Provider, Name: f.Org, Reviewers: []string{}, Error: (&domain.StageError{Stage: f.Stage, Err: f.Err}).Error()})
+	}
+	for _, res := range report.Results {
+		entry := listEntry{
+			Provider:   res.Provider,
+			Name:       res.Repo.Name,
+			URL:        res.Repo.Url,
+			Identifier: res.Outcome == domain.OutcomeAccepted || res.Outcome == domain.OutcomeNotAccepted,
+			Accepted:   res.Outcome == domain.OutcomeAccepted,
+			Reviewers:  res.Remote.Reviewers,
+		}
+		if entry.Reviewers == nil {
+			entry.Reviewers = []string{}
+		}
+		if res.Err != nil {
+			entry.Error = res.Err.Error()
+		}
+		entries = append(entries, entry)
+	}
+
+	if format == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROVIDER\tNAME\tURL\tIDENTIFIER\tACCEPTED\tREVIEWERS\tERROR")
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Provider, e.Name, e.URL, yesNo(e.Identifier), yesNo(e.Accepted), strings.Join(e.Reviewers, ","), e.Error)
+	}
+	return tw.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func changeMarker(status domain.ChangeStatus) string {
+	switch status {
+	case domain.ChangeAdded:
+		return "+"
+	case domain.ChangeModified:
+		return "~"
+	default:
+		return "="
+	}
+}
+
+func resultDetail(res domain.RepoResult) string {
+	switch {
+	case res.Err != nil:
+		return res.Err.Error()
+	case res.PR.URL != "":
+		return res.PR.URL
+	default:
+		return ""
+	}
+}
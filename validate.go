@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/knadh/koanf/v2"
+	"github.com/urfave/cli/v3"
+
+	"go.iain.rocks/boneclone/app/domain"
+	"go.iain.rocks/boneclone/app/infra/git/repository_providers"
+)
+
+// validateAction loads the config exactly like a run does and reports every problem found.
+func validateAction(_ context.Context, c *cli.Command) error {
+	var problems []domain.ConfigProblem
+	config, err := loadConfig(c)
+	if err != nil {
+		problems = append(problems, domain.ConfigProblem{Field: c.String("config"), Message: err.Error()})
+	} else {
+		problems = validateConfig(config)
+	}
+	return reportProblems(os.Stdout, problems)
+}
+
+// validateConfig combines the domain, provider and filesystem checks for a loaded config.
+func validateConfig(config domain.Config) []domain.ConfigProblem {
+	problems := unknownKeyProblems()
+	problems = append(problems, config.Validate()...)
+	for i, pp := range config.Providers {
+		field := fmt.Sprintf("providers[%d]", i)
+		problems = append(problems, repository_providers.ValidateProviderConfig(field, pp)...)
+		if config.Git.PullRequest && strings.EqualFold(pp.Provider, repository_providers.ProviderLocal) {
+			problems = append(problems, domain.ConfigProblem{Field: "git.pullRequest", Message: fmt.Sprintf("must be false when using the local provider (%s), which cannot open pull requests", field)})
+		}
+	}
+	return append(problems, includeProblems(config.Files.Include)...)
+}
+
+// includeProblems reports files.include entries that do not exist relative to the working directory.
+func includeProblems(include []string) []domain.ConfigProblem {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return []domain.ConfigProblem{{Field: "files.include", Message: fmt.Sprintf("cannot resolve paths against the working directory: %v", err)}}
+	}
+
+	var problems []domain.ConfigProblem
+	for i, inc := range include {
+		if inc == "" {
+			continue
+		}
+		if _, err := os.Stat(inc); err != nil {
+			problems = append(problems, domain.ConfigProblem{
+				Field:   fmt.Sprintf("files.include[%d]", i),
+				Message: fmt.Sprintf("%q does not exist relative to %s", inc, filepath.Clean(cwd)),
+			})
+		}
+	}
+	return problems
+}
+
+// unknownKeyProblems reports config keys that do not map to any config field, which usually means a typo.
+// A config that cannot be decoded is reported as a problem of its own, since its keys cannot be checked.
+func unknownKeyProblems() []domain.ConfigProblem {
+	var md mapstructure.Metadata
+	var discard domain.Config
+	err := k.UnmarshalWithConf("", &discard, koanf.UnmarshalConf{DecoderConfig: &mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		Metadata:         &md,
+		WeaklyTypedInput: true,
+	}})
+	if err != nil {
+		return []domain.ConfigProblem{{Field: "config", Message: fmt.Sprintf("cannot be decoded: %v", err)}}
+	}
+
+	problems := make([]domain.ConfigProblem, 0, len(md.Unused))
+	for _, key := range md.Unused {
+		problems = append(problems, domain.ConfigProblem{Field: key, Message: "unknown config key"})
+	}
+	return problems
+}
+
+// reportProblems prints each problem on its own line and returns an error when there are any.
+func reportProblems(w io.Writer, problems []domain.ConfigProblem) error {
+	if len(problems) == 0 {
+		_, err := fmt.Fprintln(w, "config is valid")
+		return err
+	}
+	for _, p := range problems {
+		if _, err := fmt.Fprintln(w, p.String()); err != nil {
+			return err
+		}
+	}
+	return &exitError{code: exitCodeError, msg: fmt.Sprintf("%d config problem(s) found", len(problems))}
+}